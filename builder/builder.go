@@ -37,6 +37,8 @@ type TypeToSqlInsertCase struct {
 	OnDuplicateKeys []string
 	UpdateFields    []string
 	MustColumn      []string
+	UsingColumns    []string // INSERT INTO t (UsingColumns) SELECT ..., 与 Using 一起使用
+	Using           IBuilder // 插入数据的子查询, 不为空时忽略插入的数据
 }
 
 type TypeLock int8
@@ -45,4 +47,16 @@ const (
 	TypeLockUnknown TypeLock = iota
 	TypeLockInShareMode
 	TypeLockForUpdate
+	TypeLockForNoKeyUpdate // postgresql FOR NO KEY UPDATE, 其他数据库按 FOR UPDATE 处理
+)
+
+// LockOptions 行锁选项, 不支持的数据库忽略
+type LockOptions struct {
+	Wait string   // 空为等待, 见 LockNoWait, LockSkipLocked
+	Of   []string // 只锁定的表, 如 FOR UPDATE OF jobs
+}
+
+const (
+	LockNoWait     = "nowait"      // 无法加锁时立即报错
+	LockSkipLocked = "skip locked" // 跳过已锁定的行, 如任务队列
 )
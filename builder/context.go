@@ -10,8 +10,10 @@ type Context struct {
 	OrderByClause     OrderByClause
 	LimitOffsetClause LimitOffsetClause
 	UnionClause       UnionClause
+	TenantClause      TenantClause
 
 	PessimisticLocking TypeLock
+	LockOptions        LockOptions
 	Prefix             string
 }
 
@@ -19,6 +21,20 @@ func NewContext(prefix string) *Context {
 	return &Context{Prefix: prefix}
 }
 
+// Clone 深拷贝所有子句, 修改拷贝不影响原对象; 子查询(IBuilder)仍为原对象的引用
+func (db *Context) Clone() *Context {
+	var ctx = *db
+	ctx.SelectClause = db.SelectClause.Clone()
+	ctx.JoinClause = db.JoinClause.Clone()
+	ctx.WhereClause = db.WhereClause.Clone()
+	ctx.GroupClause = db.GroupClause.Clone()
+	ctx.HavingClause = db.HavingClause.Clone()
+	ctx.OrderByClause = db.OrderByClause.Clone()
+	ctx.UnionClause = db.UnionClause.Clone()
+	ctx.LockOptions.Of = append([]string(nil), db.LockOptions.Of...)
+	return &ctx
+}
+
 func (db *Context) Table(table any, alias ...string) *Context {
 	db.TableClause.Table(table, alias...)
 	return db
@@ -100,6 +116,10 @@ func (db *Context) GroupByRaw(columns ...string) *Context {
 	db.GroupClause.GroupByRaw(columns...)
 	return db
 }
+func (db *Context) GroupByExpr(exprs ...Expr) *Context {
+	db.GroupClause.GroupByExpr(exprs...)
+	return db
+}
 func (db *Context) Having(column any, argsOrClosure ...any) *Context {
 	db.HavingClause.Where(column, argsOrClosure...)
 	return db
@@ -116,7 +136,7 @@ func (db *Context) OrHavingRaw(raw string, argsOrClosure ...any) *Context {
 	db.HavingClause.OrWhereRaw(raw, argsOrClosure...)
 	return db
 }
-func (db *Context) OrderBy(column string, directions ...string) *Context {
+func (db *Context) OrderBy(column any, directions ...string) *Context {
 	db.OrderByClause.OrderBy(column, directions...)
 	return db
 }
@@ -144,3 +164,19 @@ func (db *Context) LockForUpdate() *Context {
 	db.PessimisticLocking = TypeLockForUpdate
 	return db
 }
+func (db *Context) LockForNoKeyUpdate() *Context {
+	db.PessimisticLocking = TypeLockForNoKeyUpdate
+	return db
+}
+func (db *Context) SkipLocked() *Context {
+	db.LockOptions.Wait = LockSkipLocked
+	return db
+}
+func (db *Context) NoWait() *Context {
+	db.LockOptions.Wait = LockNoWait
+	return db
+}
+func (db *Context) LockOf(tables ...string) *Context {
+	db.LockOptions.Of = append(db.LockOptions.Of, tables...)
+	return db
+}
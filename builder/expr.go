@@ -0,0 +1,11 @@
+package builder
+
+// Expr 原生 sql 表达式, 作为值(insert/update 数据, where 条件的值)或列(where/join/order by/group by)时原样输出,
+// Binds 按顺序合并到语句的绑定参数中, 如:
+//
+//	Expr{Sql: "NOW()"}
+//	Expr{Sql: "COALESCE(nickname, ?)", Binds: []any{"guest"}}
+type Expr struct {
+	Sql   string
+	Binds []any
+}
@@ -3,11 +3,18 @@ package builder
 type TypeGroupItem struct {
 	Column string
 	IsRaw  bool
+	Binds  []any // 原生 sql 表达式的绑定数据, 见 Expr
 }
 type GroupClause struct {
 	Groups []TypeGroupItem
 }
 
+// Clone 深拷贝
+func (db GroupClause) Clone() GroupClause {
+	db.Groups = append([]TypeGroupItem(nil), db.Groups...)
+	return db
+}
+
 // GroupBy 添加 GROUP BY 子句
 func (db *GroupClause) GroupBy(columns ...string) {
 	for _, col := range columns {
@@ -24,3 +31,14 @@ func (db *GroupClause) GroupByRaw(columns ...string) {
 		})
 	}
 }
+
+// GroupByExpr 以表达式分组, 如: GroupByExpr(Expr{Sql: "DATE_FORMAT(created_at, ?)", Binds: []any{"%Y-%m"}})
+func (db *GroupClause) GroupByExpr(exprs ...Expr) {
+	for _, expr := range exprs {
+		db.Groups = append(db.Groups, TypeGroupItem{
+			Column: expr.Sql,
+			IsRaw:  true,
+			Binds:  expr.Binds,
+		})
+	}
+}
@@ -4,3 +4,8 @@ package builder
 type HavingClause struct {
 	WhereClause
 }
+
+// Clone 深拷贝
+func (h HavingClause) Clone() HavingClause {
+	return HavingClause{h.WhereClause.Clone()}
+}
@@ -25,6 +25,12 @@ type JoinClause struct {
 	Err       error
 }
 
+// Clone 深拷贝, 子查询仍为原对象的引用
+func (jc JoinClause) Clone() JoinClause {
+	jc.JoinItems = append([]any(nil), jc.JoinItems...)
+	return jc
+}
+
 // TypeJoinSub 描述JOIN操作
 type TypeJoinSub struct {
 	IBuilder
@@ -35,6 +41,8 @@ type TypeJoinStandard struct {
 	Column1  string
 	Operator string
 	Column2  string
+	IsRaw    bool  // Column2 为原生 sql 表达式, 见 Expr
+	Binds    []any // Column2 表达式的绑定数据
 }
 type TypeJoinOn struct {
 	TableClause
@@ -98,27 +106,32 @@ func (db *JoinClause) join(joinType string, table any, argOrFn ...any) *JoinClau
 			})
 		}
 	case 2:
-		db.JoinItems = append(db.JoinItems, TypeJoinStandard{
-			TableClause: tab,
-			Column1:     argOrFn[0].(string),
-			Operator:    "=",
-			Column2:     argOrFn[1].(string),
-			Type:        joinType,
-		})
+		db.JoinItems = append(db.JoinItems, db.joinStandard(joinType, tab, argOrFn[0], "=", argOrFn[1]))
 	case 3:
-		db.JoinItems = append(db.JoinItems, TypeJoinStandard{
-			TableClause: tab,
-			Column1:     argOrFn[0].(string),
-			Operator:    argOrFn[1].(string),
-			Column2:     argOrFn[2].(string),
-			Type:        joinType,
-		})
+		db.JoinItems = append(db.JoinItems, db.joinStandard(joinType, tab, argOrFn[0], argOrFn[1], argOrFn[2]))
 	default:
 		db.Err = errors.New("join args error")
 	}
 	return db
 }
 
+// joinStandard column2 可以为 Expr, 如: Join("card", "card.uid", "=", Expr{Sql: "users.id + ?", Binds: []any{1}})
+func (db *JoinClause) joinStandard(joinType string, tab TableClause, column1, operator, column2 any) (item TypeJoinStandard) {
+	item = TypeJoinStandard{TableClause: tab, Type: joinType}
+	item.Column1, _ = column1.(string)
+	item.Operator, _ = operator.(string)
+	switch v := column2.(type) {
+	case Expr:
+		item.Column2, item.IsRaw, item.Binds = v.Sql, true, v.Binds
+	case string:
+		item.Column2 = v
+	}
+	if item.Column1 == "" || item.Operator == "" || item.Column2 == "" {
+		db.Err = errors.New("join args error")
+	}
+	return
+}
+
 func (db *JoinClause) Join(table any, argOrFn ...any) *JoinClause {
 	return db.join("INNER JOIN", table, argOrFn...)
 }
@@ -4,23 +4,39 @@ type OrderByItem struct {
 	Column    string
 	Direction string // "asc" 或 "desc"
 	IsRaw     bool
+	Binds     []any // 原生 sql 表达式的绑定数据, 见 Expr
 }
 
 // OrderByClause 存储排序信息。
 type OrderByClause struct {
 	Columns []OrderByItem
+	Err     error
+}
+
+// Clone 深拷贝
+func (db OrderByClause) Clone() OrderByClause {
+	db.Columns = append([]OrderByItem(nil), db.Columns...)
+	return db
 }
 
 // OrderBy adds an ORDER BY clause to the query.
-func (db *OrderByClause) OrderBy(column string, directions ...string) {
+//
+// column 为列名或 Expr, 如: OrderBy(Expr{Sql: "FIELD(status, ?, ?)", Binds: []any{"paid", "new"}}, "DESC")
+func (db *OrderByClause) OrderBy(column any, directions ...string) {
 	var direction string
 	if len(directions) > 0 {
 		direction = directions[0]
 	}
-	db.Columns = append(db.Columns, OrderByItem{
-		Column:    column,
-		Direction: direction,
-	})
+	var item = OrderByItem{Direction: direction}
+	switch v := column.(type) {
+	case Expr:
+		item.Column, item.IsRaw, item.Binds = v.Sql, true, v.Binds
+	case string:
+		item.Column = v
+	default:
+		return
+	}
+	db.Columns = append(db.Columns, item)
 }
 
 // OrderByRaw adds a Raw ORDER BY clause to the query.
@@ -14,6 +14,18 @@ type Column struct {
 type SelectClause struct {
 	Columns  []Column
 	Distinct bool
+	Err      error
+}
+
+// Clone 深拷贝
+func (db SelectClause) Clone() SelectClause {
+	var columns = make([]Column, len(db.Columns))
+	for i, col := range db.Columns {
+		col.Binds = append([]any(nil), col.Binds...)
+		columns[i] = col
+	}
+	db.Columns = columns
+	return db
 }
 
 // Select specifies the columns to retrieve.
@@ -0,0 +1,9 @@
+package builder
+
+// TenantClause 多租户隔离条件
+//
+// Column 不为空时, Driver 构建的 SELECT/UPDATE/DELETE 都会带上 Column = Value 条件, INSERT 会自动填充该字段
+type TenantClause struct {
+	Column string
+	Value  any
+}
@@ -8,6 +8,12 @@ type UnionClause struct {
 	Unions []UnionItem
 }
 
+// Clone 深拷贝, union 的查询仍为原对象的引用
+func (u UnionClause) Clone() UnionClause {
+	u.Unions = append([]UnionItem(nil), u.Unions...)
+	return u
+}
+
 func (u *UnionClause) Union(b ...IBuilder) *UnionClause {
 	for _, v := range b {
 		u.Unions = append(u.Unions, UnionItem{IBuilder: v})
@@ -2,10 +2,12 @@ package builder
 
 import (
 	"errors"
+	"fmt"
 	"reflect"
 	"slices"
 	"sort"
 	"strings"
+	"time"
 )
 
 type IWhere interface {
@@ -39,6 +41,29 @@ type IWhere interface {
 
 	WhereExists(clause IBuilder) IWhere
 	WhereNotExists(clause IBuilder) IWhere
+
+	WhereColumn(column1 string, operatorOrColumn2 ...string) IWhere
+	OrWhereColumn(column1 string, operatorOrColumn2 ...string) IWhere
+	WhereDate(column string, args ...any) IWhere
+	OrWhereDate(column string, args ...any) IWhere
+	WhereTime(column string, args ...any) IWhere
+	OrWhereTime(column string, args ...any) IWhere
+	WhereYear(column string, args ...any) IWhere
+	OrWhereYear(column string, args ...any) IWhere
+	WhereMonth(column string, args ...any) IWhere
+	OrWhereMonth(column string, args ...any) IWhere
+	WhereDay(column string, args ...any) IWhere
+	OrWhereDay(column string, args ...any) IWhere
+
+	WhereJsonContains(column string, value any) IWhere
+	OrWhereJsonContains(column string, value any) IWhere
+	WhereJsonDoesntContain(column string, value any) IWhere
+	OrWhereJsonDoesntContain(column string, value any) IWhere
+	WhereJsonLength(column string, args ...any) IWhere
+	OrWhereJsonLength(column string, args ...any) IWhere
+
+	WhereFullText(columns []string, query string, options ...FullTextOptions) IWhere
+	OrWhereFullText(columns []string, query string, options ...FullTextOptions) IWhere
 }
 
 type WhereNestedHandler func(where IWhere)
@@ -50,6 +75,21 @@ type WhereClause struct {
 	Not        bool
 	Err        error
 }
+
+// Clone 深拷贝, 子查询仍为原对象的引用
+func (w WhereClause) Clone() WhereClause {
+	var conditions = make([]any, len(w.Conditions))
+	for i, v := range w.Conditions {
+		if raw, ok := v.(TypeWhereRaw); ok {
+			raw.Bindings = append([]any(nil), raw.Bindings...)
+			v = raw
+		}
+		conditions[i] = v
+	}
+	w.Conditions = conditions
+	return w
+}
+
 type TypeWhereRaw struct {
 	LogicalOp string
 	Column    string
@@ -93,6 +133,60 @@ type TypeWhereExists struct {
 	IBuilder
 	Not bool
 }
+type TypeWhereColumn struct {
+	LogicalOp string
+	Column1   string
+	Operator  string
+	Column2   string
+}
+type TypeWhereDate struct {
+	LogicalOp string
+	Column    string
+	Part      string // 日期时间的部分, 见 DatePartDate 等
+	Operator  string
+	Value     any
+}
+type TypeWhereJsonContains struct {
+	LogicalOp string
+	Column    string // json 列, 可带路径, 如 meta->tags
+	Value     any
+	Not       bool
+}
+type TypeWhereJsonLength struct {
+	LogicalOp string
+	Column    string // json 列, 可带路径, 如 meta->tags
+	Operator  string
+	Value     any
+}
+type TypeWhereFullText struct {
+	LogicalOp string
+	Columns   []string
+	Query     string
+	Options   FullTextOptions
+}
+
+// FullTextOptions 全文检索选项
+type FullTextOptions struct {
+	Mode     string // 检索模式, 见 FullTextBoolean 等, 空为方言默认
+	Language string // 检索语言, 如 postgresql 的 english, mssql 的 English
+}
+
+// FullTextOptions.Mode, 方言不支持的模式按默认模式处理
+const (
+	FullTextBoolean   = "boolean"   // mysql IN BOOLEAN MODE, postgresql websearch_to_tsquery, mssql CONTAINS
+	FullTextNatural   = "natural"   // mysql IN NATURAL LANGUAGE MODE, postgresql plainto_tsquery, mssql FREETEXT
+	FullTextExpansion = "expansion" // mysql WITH QUERY EXPANSION, mssql FREETEXT
+	FullTextWebsearch = "websearch" // postgresql websearch_to_tsquery
+)
+
+// WhereDate 等比较的日期时间部分
+const (
+	DatePartDate  = "date"
+	DatePartTime  = "time"
+	DatePartYear  = "year"
+	DatePartMonth = "month"
+	DatePartDay   = "day"
+)
 
 // WhereRaw Add a raw where clause to the query.
 //
@@ -186,6 +280,10 @@ func (w *WhereClause) where(boolean string, column any, args ...any) IWhere {
 	if column == nil {
 		return w
 	}
+	if expr, ok := column.(Expr); ok && len(args) == 0 {
+		w.addTypeWhereRaw(boolean, expr.Sql, expr.Binds)
+		return w
+	}
 	switch len(args) {
 	case 0:
 		rfv := reflect.Indirect(reflect.ValueOf(column))
@@ -226,13 +324,17 @@ func (w *WhereClause) where(boolean string, column any, args ...any) IWhere {
 			w.Err = errors.New("not supported where params")
 		}
 	case 1:
-		//if driver.IsExpression(column) {
-		//	return w.whereRaw(boolean, column.(string), args...)
-		//}
 		return w.where(boolean, column, "=", args[0], boolean)
 	case 2:
 		return w.where(boolean, column, args[0], args[1], boolean)
 	case 3:
+		if expr, ok := column.(Expr); ok {
+			return w.whereExpr(args[2].(string), expr, args[0].(string), args[1])
+		}
+		if _, ok := column.(string); !ok {
+			w.Err = errors.New("not supported where params")
+			return w
+		}
 		rfv := reflect.Indirect(reflect.ValueOf(args[1]))
 		if rfv.Kind() == reflect.Slice { // in/between
 			var operators = []string{"in", "not in"}
@@ -262,6 +364,49 @@ func (w *WhereClause) where(boolean string, column any, args ...any) IWhere {
 	return w
 }
 
+// whereExpr 以表达式为列的条件, 如: Where(Expr{Sql: "LOWER(name)"}, "=", "x"), 支持 in/between, 不支持子查询
+func (w *WhereClause) whereExpr(boolean string, expr Expr, operator string, value any) IWhere {
+	switch value.(type) {
+	case IBuilder, func(*Context), WhereSubHandler:
+		w.Err = errors.New("expression column not supported with sub query")
+		return w
+	}
+	var values = []any{value}
+	var op = strings.ToLower(operator)
+	if slices.Contains([]string{"in", "not in", "between", "not between"}, op) {
+		if reflect.Indirect(reflect.ValueOf(value)).Kind() != reflect.Slice {
+			w.Err = errors.New("not supported where params")
+			return w
+		}
+		values = toSlice(value)
+	}
+	var binds = slices.Clip(expr.Binds)
+	var placeholders []string
+	for _, v := range values {
+		if e, ok := v.(Expr); ok {
+			placeholders = append(placeholders, e.Sql)
+			binds = append(binds, e.Binds...)
+		} else {
+			placeholders = append(placeholders, "?")
+			binds = append(binds, v)
+		}
+	}
+	switch op {
+	case "in", "not in":
+		if len(placeholders) == 0 {
+			return w
+		}
+		return w.addTypeWhereRaw(boolean, fmt.Sprintf("%s %s (%s)", expr.Sql, strings.ToUpper(op), strings.Join(placeholders, ",")), binds)
+	case "between", "not between":
+		if len(placeholders) != 2 {
+			w.Err = errors.New("between requires 2 values")
+			return w
+		}
+		return w.addTypeWhereRaw(boolean, fmt.Sprintf("%s %s %s AND %s", expr.Sql, strings.ToUpper(op), placeholders[0], placeholders[1]), binds)
+	}
+	return w.addTypeWhereRaw(boolean, fmt.Sprintf("%s %s %s", expr.Sql, operator, placeholders[0]), binds)
+}
+
 // WhereBetween 在指定列的值位于给定范围内时添加一个"where"条件。
 //
 // relation: and/or
@@ -385,6 +530,104 @@ func (w *WhereClause) whereExists(clause IBuilder, not ...bool) IWhere {
 	return w
 }
 
+// WhereColumn 比较两列的值, 两边都作为列名, 不作为绑定值, 如:
+//
+//	WhereColumn("updated_at", ">", "created_at")
+//	WhereColumn("users.id", "orders.user_id") // 省略操作符时为 =
+func (w *WhereClause) WhereColumn(column1 string, operatorOrColumn2 ...string) IWhere {
+	return w.whereColumn("AND", column1, operatorOrColumn2...)
+}
+func (w *WhereClause) OrWhereColumn(column1 string, operatorOrColumn2 ...string) IWhere {
+	return w.whereColumn("OR", column1, operatorOrColumn2...)
+}
+func (w *WhereClause) whereColumn(boolean string, column1 string, operatorOrColumn2 ...string) IWhere {
+	switch len(operatorOrColumn2) {
+	case 1:
+		w.Conditions = append(w.Conditions, TypeWhereColumn{LogicalOp: boolean, Column1: column1, Operator: "=", Column2: operatorOrColumn2[0]})
+	case 2:
+		w.Conditions = append(w.Conditions, TypeWhereColumn{LogicalOp: boolean, Column1: column1, Operator: operatorOrColumn2[0], Column2: operatorOrColumn2[1]})
+	default:
+		w.Err = errors.New("not supported where column params")
+	}
+	return w
+}
+
+// WhereDate 比较列的日期部分, 按方言生成 DATE(col), col::date, CAST(col AS DATE) 等, 如:
+//
+//	WhereDate("created_at", "2024-01-02")
+//	WhereDate("created_at", ">=", time.Now()) // time.Time 按比较的部分取值, 如 "2024-01-02"
+//
+// WhereTime/WhereYear/WhereMonth/WhereDay 同理, 分别比较时间(15:04:05), 年, 月, 日
+func (w *WhereClause) WhereDate(column string, args ...any) IWhere {
+	return w.whereDate("AND", DatePartDate, column, args...)
+}
+func (w *WhereClause) OrWhereDate(column string, args ...any) IWhere {
+	return w.whereDate("OR", DatePartDate, column, args...)
+}
+func (w *WhereClause) WhereTime(column string, args ...any) IWhere {
+	return w.whereDate("AND", DatePartTime, column, args...)
+}
+func (w *WhereClause) OrWhereTime(column string, args ...any) IWhere {
+	return w.whereDate("OR", DatePartTime, column, args...)
+}
+func (w *WhereClause) WhereYear(column string, args ...any) IWhere {
+	return w.whereDate("AND", DatePartYear, column, args...)
+}
+func (w *WhereClause) OrWhereYear(column string, args ...any) IWhere {
+	return w.whereDate("OR", DatePartYear, column, args...)
+}
+func (w *WhereClause) WhereMonth(column string, args ...any) IWhere {
+	return w.whereDate("AND", DatePartMonth, column, args...)
+}
+func (w *WhereClause) OrWhereMonth(column string, args ...any) IWhere {
+	return w.whereDate("OR", DatePartMonth, column, args...)
+}
+func (w *WhereClause) WhereDay(column string, args ...any) IWhere {
+	return w.whereDate("AND", DatePartDay, column, args...)
+}
+func (w *WhereClause) OrWhereDay(column string, args ...any) IWhere {
+	return w.whereDate("OR", DatePartDay, column, args...)
+}
+func (w *WhereClause) whereDate(boolean string, part string, column string, args ...any) IWhere {
+	var operator = "="
+	var value any
+	switch len(args) {
+	case 1:
+		value = args[0]
+	case 2:
+		op, ok := args[0].(string)
+		if !ok {
+			w.Err = errors.New("where date operator must be string")
+			return w
+		}
+		operator, value = op, args[1]
+	default:
+		w.Err = errors.New("not supported where date params")
+		return w
+	}
+	if t, ok := value.(time.Time); ok {
+		value = datePartValue(part, t)
+	}
+	w.Conditions = append(w.Conditions, TypeWhereDate{LogicalOp: boolean, Column: column, Part: part, Operator: operator, Value: value})
+	return w
+}
+
+// datePartValue 取 time.Time 中要比较的部分
+func datePartValue(part string, t time.Time) any {
+	switch part {
+	case DatePartTime:
+		return t.Format("15:04:05")
+	case DatePartYear:
+		return t.Year()
+	case DatePartMonth:
+		return int(t.Month())
+	case DatePartDay:
+		return t.Day()
+	default:
+		return t.Format("2006-01-02")
+	}
+}
+
 func (w *WhereClause) WhereNot(column any, args ...any) IWhere {
 	w.Not = true
 	return w.Where(column, args...)
@@ -422,3 +665,78 @@ func (w *WhereClause) addTypeWhereStandard(boolean string, column string, operat
 	w.Conditions = append(w.Conditions, TypeWhereStandard{LogicalOp: boolean, Column: column, Operator: operator, Value: value})
 	return w
 }
+
+// WhereJsonContains json 数组包含某个值, 列可带路径, 如:
+//
+//	WhereJsonContains("meta->tags", "go")
+//	WhereJsonContains("options->languages", []string{"en", "de"}) // mysql, postgresql 支持数组和对象
+func (w *WhereClause) WhereJsonContains(column string, value any) IWhere {
+	return w.whereJsonContains("AND", column, value, false)
+}
+func (w *WhereClause) OrWhereJsonContains(column string, value any) IWhere {
+	return w.whereJsonContains("OR", column, value, false)
+}
+func (w *WhereClause) WhereJsonDoesntContain(column string, value any) IWhere {
+	return w.whereJsonContains("AND", column, value, true)
+}
+func (w *WhereClause) OrWhereJsonDoesntContain(column string, value any) IWhere {
+	return w.whereJsonContains("OR", column, value, true)
+}
+func (w *WhereClause) whereJsonContains(boolean string, column string, value any, not bool) IWhere {
+	w.Conditions = append(w.Conditions, TypeWhereJsonContains{LogicalOp: boolean, Column: column, Value: value, Not: not})
+	return w
+}
+
+// WhereJsonLength 比较 json 数组的长度, 如:
+//
+//	WhereJsonLength("meta->tags", 2)
+//	WhereJsonLength("meta->tags", ">", 1)
+func (w *WhereClause) WhereJsonLength(column string, args ...any) IWhere {
+	return w.whereJsonLength("AND", column, args...)
+}
+func (w *WhereClause) OrWhereJsonLength(column string, args ...any) IWhere {
+	return w.whereJsonLength("OR", column, args...)
+}
+func (w *WhereClause) whereJsonLength(boolean string, column string, args ...any) IWhere {
+	var operator = "="
+	var value any
+	switch len(args) {
+	case 1:
+		value = args[0]
+	case 2:
+		op, ok := args[0].(string)
+		if !ok {
+			w.Err = errors.New("where json length operator must be string")
+			return w
+		}
+		operator, value = op, args[1]
+	default:
+		w.Err = errors.New("not supported where json length params")
+		return w
+	}
+	w.Conditions = append(w.Conditions, TypeWhereJsonLength{LogicalOp: boolean, Column: column, Operator: operator, Value: value})
+	return w
+}
+
+// WhereFullText 全文检索, 按方言生成 MATCH ... AGAINST, to_tsvector @@ plainto_tsquery, fts5 MATCH, CONTAINS 等, 如:
+//
+//	WhereFullText([]string{"title", "body"}, "golang orm")
+//	WhereFullText([]string{"body"}, "+golang -php", FullTextOptions{Mode: FullTextBoolean})
+func (w *WhereClause) WhereFullText(columns []string, query string, options ...FullTextOptions) IWhere {
+	return w.whereFullText("AND", columns, query, options...)
+}
+func (w *WhereClause) OrWhereFullText(columns []string, query string, options ...FullTextOptions) IWhere {
+	return w.whereFullText("OR", columns, query, options...)
+}
+func (w *WhereClause) whereFullText(boolean string, columns []string, query string, options ...FullTextOptions) IWhere {
+	if len(columns) == 0 {
+		w.Err = errors.New("full text columns required")
+		return w
+	}
+	var item = TypeWhereFullText{LogicalOp: boolean, Columns: columns, Query: query}
+	if len(options) > 0 {
+		item.Options = options[0]
+	}
+	w.Conditions = append(w.Conditions, item)
+	return w
+}
@@ -0,0 +1,263 @@
+package gorose
+
+import (
+	"container/list"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"github.com/gohouse/gorose/v3/builder"
+	"github.com/gohouse/gorose/v3/driver"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// Cache 查询结果缓存, 可以自行实现对接 redis 等存储, 默认提供内存 LRU 实现 NewMemoryCache()
+//
+// tags 为查询涉及的表名, Insert/Update/Delete 等写操作会通过 Flush(表名) 淘汰相关缓存;
+// Engin.Exec 等原生 sql 无法确定涉及的表, 不会淘汰缓存, 需要时自行调用 Flush(表名)
+type Cache interface {
+	Get(key string) (value any, ok bool)
+	Set(key string, value any, ttl time.Duration, tags ...string)
+	Delete(key string)
+	// Flush 删除打了任意一个 tag 的缓存
+	Flush(tags ...string)
+}
+
+// UseCache 设置查询结果缓存, 配合 Database.Cache()/Remember() 使用
+func (g *GoRose) UseCache(c Cache) *GoRose {
+	g.cache = c
+	return g
+}
+
+type rememberOption struct {
+	key string
+	ttl time.Duration
+}
+
+// Cache 缓存本次查询结果(Get/First/Find/To/Count/Paginate 等)
+//
+//	key: 缓存 key, 为空时使用 sql 和绑定参数生成; 聚合查询会加上函数名后缀, 如 users:count
+//	ttl: 缓存时间
+//
+// 事务中不使用缓存, 写入和命中缓存时都复制结果, 调用方修改结果不影响缓存
+func (db *Database) Cache(key string, ttl time.Duration) *Database {
+	db = db.builder()
+	db.remember = &rememberOption{key: key, ttl: ttl}
+	return db
+}
+
+// Remember 同 Cache, 使用 sql 和绑定参数生成缓存 key
+func (db *Database) Remember(ttl time.Duration) *Database {
+	return db.Cache("", ttl)
+}
+
+// rememberTo 开启缓存时, 优先从缓存中获取结果并赋值给 bind, 否则执行 fetch 并缓存 bind 的结果
+func (db *Database) rememberTo(suffix string, bind any, query string, args []any, fetch func() error) (err error) {
+	if db.remember == nil || db.cache == nil || db.tx != nil {
+		return fetch()
+	}
+	var key = db.remember.key
+	if key == "" {
+		sum := sha1.Sum([]byte(fmt.Sprintf("%s|%#v", query, args)))
+		key = "gorose:" + hex.EncodeToString(sum[:])
+	} else if suffix != "" {
+		key = fmt.Sprintf("%s:%s", key, suffix)
+	}
+	rfv := reflect.Indirect(reflect.ValueOf(bind))
+	if v, ok := db.cache.Get(key); ok {
+		if cached := reflect.ValueOf(v); cached.IsValid() && cached.Type().AssignableTo(rfv.Type()) {
+			rfv.Set(deepCopy(cached))
+			return
+		}
+	}
+	if err = fetch(); err != nil {
+		return
+	}
+	db.cache.Set(key, deepCopy(rfv).Interface(), db.remember.ttl, db.cacheTags(db.Context.TableClause)...)
+	return
+}
+
+// deepCopy 复制缓存的结果, 包括 map, slice, 指针和结构体的可导出字段
+func deepCopy(v reflect.Value) reflect.Value {
+	switch v.Kind() {
+	case reflect.Map:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.MakeMapWithSize(v.Type(), v.Len())
+		iter := v.MapRange()
+		for iter.Next() {
+			res.SetMapIndex(iter.Key(), deepCopy(iter.Value()))
+		}
+		return res
+	case reflect.Slice:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.MakeSlice(v.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			res.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return res
+	case reflect.Array:
+		res := reflect.New(v.Type()).Elem()
+		for i := 0; i < v.Len(); i++ {
+			res.Index(i).Set(deepCopy(v.Index(i)))
+		}
+		return res
+	case reflect.Ptr:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.New(v.Type().Elem())
+		res.Elem().Set(deepCopy(v.Elem()))
+		return res
+	case reflect.Interface:
+		if v.IsNil() {
+			return v
+		}
+		res := reflect.New(v.Type()).Elem()
+		res.Set(deepCopy(v.Elem()))
+		return res
+	case reflect.Struct:
+		res := reflect.New(v.Type()).Elem()
+		res.Set(v)
+		for i := 0; i < v.NumField(); i++ {
+			if res.Field(i).CanSet() {
+				res.Field(i).Set(deepCopy(v.Field(i)))
+			}
+		}
+		return res
+	default:
+		return v
+	}
+}
+
+// cacheTags 查询涉及的表名, 包括 join 的表
+func (db *Database) cacheTags(tab builder.TableClause) (tags []string) {
+	if name := driver.TableName(tab, db.Context.Prefix); name != "" {
+		tags = append(tags, name)
+	}
+	for _, v := range db.Context.JoinClause.JoinItems {
+		var name string
+		switch item := v.(type) {
+		case builder.TypeJoinStandard:
+			name = driver.TableName(item.TableClause, db.Context.Prefix)
+		case builder.TypeJoinOn:
+			name = driver.TableName(item.TableClause, db.Context.Prefix)
+		}
+		if name != "" { // 子查询没有表名
+			tags = append(tags, name)
+		}
+	}
+	return
+}
+
+// forgetCache 写操作后淘汰表相关的缓存, obj 为结构体时以结构体对应的表为准;
+// 事务中在提交成功后才淘汰, 避免提交前的并发查询把旧数据重新写入缓存; DryRun 时没有写入, 不淘汰
+func (db *Database) forgetCache(obj any) {
+	var cache = db.cache
+	if cache == nil || db.dryRun != nil {
+		return
+	}
+	var tab = db.Context.TableClause
+	rfv := reflect.Indirect(reflect.ValueOf(obj))
+	if rfv.Kind() == reflect.Struct || (rfv.Kind() == reflect.Slice && rfv.Type().Elem().Kind() == reflect.Struct) {
+		tab = builder.TableClause{Tables: obj}
+	}
+	if name := driver.TableName(tab, db.Context.Prefix); name != "" {
+		db.Engin.AfterCommit(func() { cache.Flush(name) })
+	}
+}
+
+// MemoryCache 内存 LRU 缓存, 实现了 Cache 接口
+type MemoryCache struct {
+	size  int
+	mu    sync.Mutex
+	ll    *list.List // *memoryCacheItem, 最近使用的在前
+	items map[string]*list.Element
+	tags  map[string]map[string]struct{} // tag => keys
+}
+type memoryCacheItem struct {
+	key    string
+	value  any
+	expire time.Time
+	tags   []string
+}
+
+// NewMemoryCache 内存 LRU 缓存, 最多缓存 size 条, 超出时淘汰最久未使用的
+func NewMemoryCache(size int) *MemoryCache {
+	return &MemoryCache{size: size, ll: list.New(), items: map[string]*list.Element{}, tags: map[string]map[string]struct{}{}}
+}
+
+func (c *MemoryCache) Get(key string) (value any, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	el, ok := c.items[key]
+	if !ok {
+		return
+	}
+	item := el.Value.(*memoryCacheItem)
+	if !item.expire.IsZero() && time.Now().After(item.expire) {
+		c.remove(el)
+		return nil, false
+	}
+	c.ll.MoveToFront(el)
+	return item.value, true
+}
+
+func (c *MemoryCache) Set(key string, value any, ttl time.Duration, tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+	var item = &memoryCacheItem{key: key, value: value, tags: tags}
+	if ttl > 0 {
+		item.expire = time.Now().Add(ttl)
+	}
+	c.items[key] = c.ll.PushFront(item)
+	for _, tag := range tags {
+		if c.tags[tag] == nil {
+			c.tags[tag] = map[string]struct{}{}
+		}
+		c.tags[tag][key] = struct{}{}
+	}
+	for c.size > 0 && c.ll.Len() > c.size {
+		c.remove(c.ll.Back())
+	}
+}
+
+func (c *MemoryCache) Delete(key string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := c.items[key]; ok {
+		c.remove(el)
+	}
+}
+
+func (c *MemoryCache) Flush(tags ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, tag := range tags {
+		for key := range c.tags[tag] {
+			if el, ok := c.items[key]; ok {
+				c.remove(el)
+			}
+		}
+		delete(c.tags, tag)
+	}
+}
+
+func (c *MemoryCache) remove(el *list.Element) {
+	item := el.Value.(*memoryCacheItem)
+	c.ll.Remove(el)
+	delete(c.items, item.key)
+	for _, tag := range item.tags {
+		delete(c.tags[tag], item.key)
+		if len(c.tags[tag]) == 0 {
+			delete(c.tags, tag)
+		}
+	}
+}
@@ -0,0 +1,153 @@
+package gorose
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDatabase_Remember(t *testing.T) {
+	g, fake := newFake(t)
+	g.UseCache(NewMemoryCache(10))
+	var count = func() int64 {
+		res, err := g.NewDatabase().Table("users").Where("age", ">", 18).Remember(time.Minute).Count()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	var queried = fake.queried.Load()
+	if count() != 1 || count() != 1 {
+		t.Error("unexpected count result")
+	}
+	if got := fake.queried.Load() - queried; got != 1 {
+		t.Errorf("expect 1 query, got %d", got)
+	}
+	if _, err := g.NewDatabase().Table("users").Insert(map[string]any{"age": 20}); err != nil {
+		t.Fatal(err)
+	}
+	count()
+	if got := fake.queried.Load() - queried; got != 2 {
+		t.Errorf("expect cache flushed after insert, got %d queries", got)
+	}
+}
+
+func TestDatabase_RememberTo(t *testing.T) {
+	g, fake := newFake(t)
+	g.UseCache(NewMemoryCache(10))
+	var to = func() {
+		var posts []benchPost
+		if err := g.NewDatabase().Remember(time.Minute).To(&posts); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var queried = fake.queried.Load()
+	to()
+	to()
+	if got := fake.queried.Load() - queried; got != 1 {
+		t.Errorf("expect 1 query, got %d", got)
+	}
+	if _, err := g.NewDatabase().Insert(&benchPost{Title: "gorose"}); err != nil {
+		t.Fatal(err)
+	}
+	to()
+	if got := fake.queried.Load() - queried; got != 2 {
+		t.Errorf("expect cache flushed by struct table after insert, got %d queries", got)
+	}
+}
+
+func TestDatabase_RememberTransaction(t *testing.T) {
+	g, fake := newFake(t)
+	g.UseCache(NewMemoryCache(10))
+	var count = func() {
+		if _, err := g.NewDatabase().Table("users").Remember(time.Minute).Count(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	count()
+	var queried = fake.queried.Load()
+	err := g.NewDatabase().Transaction(func(tx TxHandler) error {
+		if _, err := tx().Table("users").Insert(map[string]any{"age": 20}); err != nil {
+			return err
+		}
+		count()
+		if got := fake.queried.Load() - queried; got != 0 {
+			t.Errorf("expect cache kept before commit, got %d queries", got)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	count()
+	if got := fake.queried.Load() - queried; got != 1 {
+		t.Errorf("expect cache flushed after commit, got %d queries", got)
+	}
+}
+
+func TestDatabase_RememberDryRun(t *testing.T) {
+	g, fake := newFake(t)
+	g.UseCache(NewMemoryCache(10))
+	var count = func() {
+		if _, err := g.NewDatabase().Table("users").Remember(time.Minute).Count(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	count()
+	var queried = fake.queried.Load()
+	var db = g.NewDatabase().DryRun().Table("users")
+	if _, err := db.Insert(map[string]any{"age": 20}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Where("id", 1).Delete(nil); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := db.Where("id", 1).Increment("age"); err != nil {
+		t.Fatal(err)
+	}
+	count()
+	if got := fake.queried.Load() - queried; got != 0 {
+		t.Errorf("expect cache kept after dry run writes, got %d queries", got)
+	}
+}
+
+func TestDatabase_RememberCopy(t *testing.T) {
+	g, fake := newFake(t)
+	g.UseCache(NewMemoryCache(10))
+	var get = func() []map[string]any {
+		res, err := g.NewDatabase().Table("users_json").Remember(time.Minute).Get()
+		if err != nil {
+			t.Fatal(err)
+		}
+		return res
+	}
+	var queried = fake.queried.Load()
+	get()[0]["id"] = int64(100)
+	if res := get(); res[0]["id"] != int64(1) {
+		t.Errorf("expect cached result not modified by caller, got %v", res[0]["id"])
+	}
+	if got := fake.queried.Load() - queried; got != 1 {
+		t.Errorf("expect 1 query, got %d", got)
+	}
+}
+
+func TestMemoryCache(t *testing.T) {
+	var c = NewMemoryCache(2)
+	c.Set("a", 1, 0, "users")
+	c.Set("b", 2, time.Nanosecond, "users")
+	c.Set("c", 3, 0, "posts")
+	if _, ok := c.Get("a"); ok {
+		t.Error("expect a evicted by lru")
+	}
+	time.Sleep(time.Millisecond)
+	if _, ok := c.Get("b"); ok {
+		t.Error("expect b expired")
+	}
+	c.Set("d", 4, 0, "users", "posts")
+	c.Flush("users")
+	if _, ok := c.Get("d"); ok {
+		t.Error("expect d flushed by tag")
+	}
+	if v, ok := c.Get("c"); !ok || v != 3 {
+		t.Error("expect c cached")
+	}
+}
@@ -31,7 +31,7 @@ func (c ConfigCluster) init() (master []*sql.DB, slave []*sql.DB) {
 	}
 	if len(c.ReadConf) > 0 {
 		for _, v := range c.ReadConf {
-			slave = append(master, c.initDB(&v))
+			slave = append(slave, c.initDB(&v))
 		}
 	}
 	return
@@ -2,9 +2,11 @@ package gorose
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 	"github.com/gohouse/gorose/v3/builder"
 	"github.com/gohouse/gorose/v3/driver"
+	"github.com/gohouse/gorose/v3/parser"
 	"reflect"
 	"strings"
 )
@@ -13,16 +15,84 @@ type Database struct {
 	*Engin
 	Driver  *driver.Driver
 	Context *builder.Context
+
+	remember  *rememberOption // 查询结果缓存, 见 Cache()
+	immutable bool            // 不可变模式, 见 Immutable()
 }
 
 func NewDatabase(g *GoRose) *Database {
+	var ctx = builder.NewContext(g.prefix)
+	ctx.TenantClause.Column = g.tenantColumn
 	return &Database{
 		Driver:  driver.NewDriver(g.driver),
 		Engin:   NewEngin(g),
-		Context: builder.NewContext(g.prefix),
+		Context: ctx,
+	}
+}
+
+// Clone 深拷贝当前查询, 修改拷贝不影响原查询, 可以将同一个基础查询分支为多个查询, 或在多个 goroutine 中复用, 如:
+//
+//	base := db.Table("users").Where("active", 1)
+//	total, err := base.Clone().Count()
+//	rows, err := base.Clone().Limit(10).Page(2).Get()
+//
+// 拷贝有各自的 LastSql; 事务中的拷贝与原查询共享事务
+func (db *Database) Clone() *Database {
+	var engin = db.Engin
+	if db.tx == nil {
+		var tmp = *db.Engin
+		engin = &tmp
+	}
+	var tmp = *db
+	tmp.Engin = engin
+	tmp.Context = db.Context.Clone()
+	return &tmp
+}
+
+// Immutable 开启不可变模式, 返回的 Database 的每个构建方法(Table/Where/Select/Limit 等)都返回新的拷贝, 不修改原查询, 如:
+//
+//	base := db.Immutable().Table("users").Where("active", 1)
+//	admins, err := base.Where("role", "admin").Get() // base 不变
+func (db *Database) Immutable() *Database {
+	var tmp = db.Clone()
+	tmp.immutable = true
+	return tmp
+}
+
+// Reset 清空查询条件和缓存设置, 保留表前缀和租户, 以便执行后复用; 不可变模式下返回新的拷贝
+func (db *Database) Reset() *Database {
+	db = db.builder()
+	var ctx = builder.NewContext(db.prefix)
+	ctx.TenantClause = db.Context.TenantClause
+	db.Context = ctx
+	db.remember = nil
+	return db
+}
+
+// builder 构建方法修改的对象, 不可变模式下为拷贝
+func (db *Database) builder() *Database {
+	if db.immutable {
+		return db.Clone()
 	}
+	return db
+}
+
+// fork 拷贝查询条件, 与原查询共享 Engin, 用于 First/Paginate 等方法在执行时不修改调用方的查询
+func (db *Database) fork() *Database {
+	var tmp = *db
+	tmp.Context = db.Context.Clone()
+	tmp.immutable = false
+	return &tmp
+}
+
+// Tenant 指定多租户模式下的租户值, 参考 GoRose.TenantMode()
+func (db *Database) Tenant(value any) *Database {
+	db = db.builder()
+	db.Context.TenantClause.Value = value
+	return db
 }
 func (db *Database) Table(table any, alias ...string) *Database {
+	db = db.builder()
 	db.Context.TableClause.Table(table, alias...)
 	return db
 }
@@ -32,54 +102,64 @@ func (db *Database) Table(table any, alias ...string) *Database {
 // Select("a.id as aid","b.id bid")
 // Select("id,nickname name")
 func (db *Database) Select(columns ...string) *Database {
+	db = db.builder()
 	db.Context.SelectClause.Select(columns...)
 	return db
 }
 
 // AddSelect 添加选择列
 func (db *Database) AddSelect(columns ...string) *Database {
+	db = db.builder()
 	db.Context.SelectClause.AddSelect(columns...)
 	return db
 }
 
 // SelectRaw 允许直接在查询中插入原始SQL片段作为选择列。
 func (db *Database) SelectRaw(raw string, binds ...any) *Database {
+	db = db.builder()
 	db.Context.SelectClause.SelectRaw(raw, binds...)
 	return db
 }
 
 // Join clause
 func (db *Database) Join(table any, argOrFn ...any) *Database {
+	db = db.builder()
 	db.Context.JoinClause.Join(table, argOrFn...)
 	return db
 }
 func (db *Database) JoinOn(table any, fn func(on builder.IJoinOn)) *Database {
+	db = db.builder()
 	db.Context.JoinClause.JoinOn(table, fn)
 	return db
 }
 
 // LeftJoin clause
 func (db *Database) LeftJoin(table any, argOrFn ...any) *Database {
+	db = db.builder()
 	db.Context.JoinClause.LeftJoin(table, argOrFn...)
 	return db
 }
 
 // RightJoin clause
 func (db *Database) RightJoin(table any, argOrFn ...any) *Database {
+	db = db.builder()
 	db.Context.JoinClause.RightJoin(table, argOrFn...)
 	return db
 }
 
 // CrossJoin clause
 func (db *Database) CrossJoin(table any, argOrFn ...any) *Database {
+	db = db.builder()
 	db.Context.JoinClause.CrossJoin(table, argOrFn...)
 	return db
 }
 func (db *Database) Where(column any, argsOrclosure ...any) *Database {
+	db = db.builder()
 	db.Context.WhereClause.Where(column, argsOrclosure...)
 	return db
 }
 func (db *Database) OrWhere(column any, argsOrclosure ...any) *Database {
+	db = db.builder()
 	db.Context.WhereClause.OrWhere(column, argsOrclosure...)
 	return db
 }
@@ -89,84 +169,138 @@ func (db *Database) OrWhere(column any, argsOrclosure ...any) *Database {
 // sql: 原生SQL条件字符串。
 // bindings: SQL绑定参数数组。
 func (db *Database) WhereRaw(raw string, bindings ...any) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereRaw(raw, bindings...)
 	return db
 }
 func (db *Database) OrWhereRaw(raw string, bindings ...any) *Database {
+	db = db.builder()
 	db.Context.WhereClause.OrWhereRaw(raw, bindings...)
 	return db
 }
 
 // GroupBy 添加 GROUP BY 子句
 func (db *Database) GroupBy(columns ...string) *Database {
+	db = db.builder()
 	db.Context.GroupClause.GroupBy(columns...)
 	return db
 }
 func (db *Database) GroupByRaw(columns ...string) *Database {
+	db = db.builder()
 	db.Context.GroupClause.GroupByRaw(columns...)
 	return db
 }
 
+// GroupByExpr 以表达式分组, 如: GroupByExpr(gorose.Raw("DATE(created_at)"))
+func (db *Database) GroupByExpr(exprs ...Expr) *Database {
+	db = db.builder()
+	db.Context.GroupClause.GroupByExpr(exprs...)
+	return db
+}
+
 // Having 添加 HAVING 子句, 同where
 func (db *Database) Having(column any, argsOrClosure ...any) *Database {
+	db = db.builder()
 	db.Context.HavingClause.Where(column, argsOrClosure...)
 	return db
 }
 func (db *Database) OrHaving(column any, argsOrClosure ...any) *Database {
+	db = db.builder()
 	db.Context.HavingClause.OrWhere(column, argsOrClosure...)
 	return db
 }
 
 // HavingRaw 添加 HAVING 子句, 同where
 func (db *Database) HavingRaw(raw string, argsOrClosure ...any) *Database {
+	db = db.builder()
 	db.Context.HavingClause.WhereRaw(raw, argsOrClosure...)
 	return db
 }
 func (db *Database) OrHavingRaw(raw string, argsOrClosure ...any) *Database {
+	db = db.builder()
 	db.Context.HavingClause.OrWhereRaw(raw, argsOrClosure...)
 	return db
 }
 
 // OrderBy adds an ORDER BY clause to the query.
-func (db *Database) OrderBy(column string, directions ...string) *Database {
+//
+// column 为列名或表达式, 如: OrderBy(gorose.Raw("FIELD(status, ?, ?)", "paid", "new"))
+func (db *Database) OrderBy(column any, directions ...string) *Database {
+	db = db.builder()
 	db.Context.OrderByClause.OrderBy(column, directions...)
 	return db
 }
 func (db *Database) OrderByRaw(column string) *Database {
+	db = db.builder()
 	db.Context.OrderByClause.OrderByRaw(column)
 	return db
 }
 
 // Limit 设置查询结果的限制数量。
 func (db *Database) Limit(limit int) *Database {
+	db = db.builder()
 	db.Context.Limit(limit)
 	return db
 }
 
 // Offset 设置查询结果的偏移量。
 func (db *Database) Offset(offset int) *Database {
+	db = db.builder()
 	db.Context.Offset(offset)
 	return db
 }
 
 // Page 页数,根据limit确定
 func (db *Database) Page(num int) *Database {
+	db = db.builder()
 	db.Context.Page(num)
 	return db
 }
 
-// SharedLock 4 select ... locking in share mode
+// SharedLock 4 select ... locking in share mode, mssql, oracle, sqlite3 不支持, 返回错误
 func (db *Database) SharedLock() *Database {
+	db = db.builder()
 	db.Context.SharedLock()
 	return db
 }
 
 // LockForUpdate 4 select ... for update
 func (db *Database) LockForUpdate() *Database {
+	db = db.builder()
 	db.Context.LockForUpdate()
 	return db
 }
 
+// LockForNoKeyUpdate 4 select ... for no key update, 只有 postgresql 支持, 其他数据库为 for update
+func (db *Database) LockForNoKeyUpdate() *Database {
+	db = db.builder()
+	db.Context.LockForNoKeyUpdate()
+	return db
+}
+
+// SkipLocked 跳过已锁定的行, 与 LockForUpdate 等一起使用, 如任务队列:
+//
+//	db.Table("jobs").Where("status", "pending").OrderBy("id").Limit(10).LockForUpdate().SkipLocked().Get()
+func (db *Database) SkipLocked() *Database {
+	db = db.builder()
+	db.Context.SkipLocked()
+	return db
+}
+
+// NoWait 无法加锁时立即报错, 与 LockForUpdate 等一起使用
+func (db *Database) NoWait() *Database {
+	db = db.builder()
+	db.Context.NoWait()
+	return db
+}
+
+// LockOf 只锁定指定的表, 如 FOR UPDATE OF jobs, 与 LockForUpdate 等一起使用; mssql, oracle, sqlite3 不支持, 返回错误
+func (db *Database) LockOf(tables ...string) *Database {
+	db = db.builder()
+	db.Context.LockOf(tables...)
+	return db
+}
+
 func (db *Database) toBind(bind any) (err error) {
 	var prepare string
 	var binds []any
@@ -185,7 +319,7 @@ func (db *Database) toBind(bind any) (err error) {
 func (db *Database) Get(columns ...string) (res []map[string]any, err error) {
 	var prepare string
 	var binds []any
-	prepare, binds, err = db.Select(columns...).ToSql()
+	prepare, binds, err = db.fork().Select(columns...).ToSql()
 	if err != nil {
 		return
 	}
@@ -193,32 +327,42 @@ func (db *Database) Get(columns ...string) (res []map[string]any, err error) {
 	err = db.queryToBindResult(&res, prepare, binds...)
 	return
 }
+
+// First 查询第一条数据, 没有数据时返回 ErrRecordNotFound
 func (db *Database) First(columns ...string) (res map[string]any, err error) {
 	var prepare string
 	var binds []any
-	prepare, binds, err = db.Select(columns...).Limit(1).ToSql()
+	prepare, binds, err = db.fork().Select(columns...).Limit(1).ToSql()
 	if err != nil {
 		return
 	}
 
 	res = make(map[string]any)
-	err = db.queryToBindResult(&res, prepare, binds...)
+	if err = db.queryToBindResult(&res, prepare, binds...); err == nil && len(res) == 0 {
+		err = ErrRecordNotFound
+	}
 	return
 }
+
+// Find 根据 id 查询, 没有数据时返回 ErrRecordNotFound
 func (db *Database) Find(id int) (res map[string]any, err error) {
 	var prepare string
 	var binds []any
-	prepare, binds, err = db.Where("id", id).Limit(1).ToSql()
+	prepare, binds, err = db.fork().Where("id", id).Limit(1).ToSql()
 	if err != nil {
 		return
 	}
 
 	res = make(map[string]any)
-	err = db.queryToBindResult(&res, prepare, binds...)
+	if err = db.queryToBindResult(&res, prepare, binds...); err == nil && len(res) == 0 {
+		err = ErrRecordNotFound
+	}
 	return
 }
 func (db *Database) queryToBindResult(bind any, query string, args ...any) (err error) {
-	return db.Engin.QueryTo(bind, query, args...)
+	return db.rememberTo("", bind, query, args, func() error {
+		return db.Engin.queryTo(bind, query, args...)
+	})
 }
 
 func (db *Database) insert(obj any, arg builder.TypeToSqlInsertCase) (res sql.Result, err error) {
@@ -227,7 +371,10 @@ func (db *Database) insert(obj any, arg builder.TypeToSqlInsertCase) (res sql.Re
 	if err != nil {
 		return res, err
 	}
-	return db.Engin.Exec(segment, binds...)
+	if res, err = db.Engin.exec(segment, binds...); err == nil {
+		db.forgetCache(obj)
+	}
+	return
 }
 func (db *Database) Insert(obj any, mustColumn ...string) (affectedRows int64, err error) {
 	result, err := db.insert(obj, builder.TypeToSqlInsertCase{MustColumn: mustColumn})
@@ -262,7 +409,8 @@ func (db *Database) InsertOrIgnore(obj any, mustColumn ...string) (affectedRows
 // Upsert 插入数据，如果存在则更新。
 //
 // 参考 https://laravel.com/docs/10.x/queries#upserts
-// 如果是mysql,则不需要填写第二个参数,MySQL会自动处理唯一索引和主键冲突问题
+// 如果是mysql,则不需要填写第二个参数,MySQL会自动处理唯一索引和主键冲突问题;
+// postgresql, sqlite3 为 ON CONFLICT (onDuplicateKeys) DO UPDATE, 必须填写; mssql, oracle 需要 MERGE, 返回错误
 //
 //	eg: Upsert(obj, []string{"id"}, []string{"age"}, "id", "name")
 func (db *Database) Upsert(obj any, onDuplicateKeys, updateFields []string, mustColumn ...string) (affectedRows int64, err error) {
@@ -284,11 +432,77 @@ func (db *Database) Replace(obj any, mustColumn ...string) (affectedRows int64,
 	return result.RowsAffected()
 }
 
+// InsertUsing 以子查询的结果插入数据, 如:
+//
+//	db.Table("users_archive").InsertUsing([]string{"id", "name"}, db.Table("users").Select("id", "name").Where("deleted", 1))
+//	=> INSERT INTO users_archive (id,name) SELECT id, name FROM users WHERE deleted = ?
+//
+// 不提供 CREATE TABLE ... AS SELECT, 各数据库写法不同(mssql 为 SELECT ... INTO), 可以使用 Engin.Exec
+func (db *Database) InsertUsing(columns []string, query builder.IBuilder) (affectedRows int64, err error) {
+	result, err := db.insert(nil, builder.TypeToSqlInsertCase{UsingColumns: columns, Using: query})
+	if err != nil {
+		return affectedRows, err
+	}
+	return result.RowsAffected()
+}
+
+// InsertOrIgnoreUsing 以子查询的结果插入数据，忽略冲突的行。参考 InsertUsing, InsertOrIgnore; mssql, oracle 不支持
+func (db *Database) InsertOrIgnoreUsing(columns []string, query builder.IBuilder) (affectedRows int64, err error) {
+	result, err := db.insert(nil, builder.TypeToSqlInsertCase{IsIgnoreCase: true, UsingColumns: columns, Using: query})
+	if err != nil {
+		return affectedRows, err
+	}
+	return result.RowsAffected()
+}
+
+// UpsertUsing 以子查询的结果插入数据，如果存在则更新。参考 InsertUsing, Upsert
+func (db *Database) UpsertUsing(columns []string, query builder.IBuilder, onDuplicateKeys, updateFields []string) (affectedRows int64, err error) {
+	result, err := db.insert(nil, builder.TypeToSqlInsertCase{OnDuplicateKeys: onDuplicateKeys, UpdateFields: updateFields, UsingColumns: columns, Using: query})
+	if err != nil {
+		return affectedRows, err
+	}
+	return result.RowsAffected()
+}
+
+// UpdateBatch 一次更新多行不同的值, 如:
+//
+//	db.Table("users").UpdateBatch([]map[string]any{{"id": 1, "score": 90}, {"id": 2, "score": 85}}, "id")
+//	=> UPDATE users SET score = CASE id WHEN ? THEN ? WHEN ? THEN ? ELSE score END WHERE id IN (?,?)
+//
+// rows 为 []map[string]any 或者结构体切片, keyColumn 为空时使用结构体的主键;
+// 超出数据库绑定参数上限时分多条语句, 并在同一个事务中执行
+func (db *Database) UpdateBatch(rows any, keyColumn string, mustColumn ...string) (affectedRows int64, err error) {
+	sqls, binds, err := db.ToSqlUpdateBatch(rows, keyColumn, mustColumn...)
+	if err != nil || len(sqls) == 0 {
+		return
+	}
+	var exec = func(*Engin) error {
+		affectedRows = 0
+		for i, segment := range sqls {
+			rowsAffected, err := db.Engin.execute(segment, binds[i]...)
+			if err != nil {
+				return err
+			}
+			affectedRows += rowsAffected
+		}
+		return nil
+	}
+	if len(sqls) > 1 {
+		err = db.Engin.Transaction(exec)
+	} else {
+		err = exec(db.Engin)
+	}
+	if err == nil {
+		db.forgetCache(rows)
+	}
+	return
+}
+
 // UpdateOrInsert 更新数据，如果存在则更新，否则插入。
 //
 // 参考 https://laravel.com/docs/10.x/queries#update-or-insert
 func (db *Database) UpdateOrInsert(conditions, data map[string]any) (affectedRows int64, err error) {
-	dbTmp := db.Where(conditions)
+	dbTmp := db.fork().Where(conditions)
 	var exists bool
 	if exists, err = dbTmp.Exists(); err != nil {
 		return
@@ -299,12 +513,30 @@ func (db *Database) UpdateOrInsert(conditions, data map[string]any) (affectedRow
 	return dbTmp.Insert(data)
 }
 
+// Update 更新数据
+//
+// obj 为结构体且带有乐观锁版本字段(`db:"version,version"`)时, 会附加 WHERE version = ? 条件并将 version 加1,
+// 没有数据被更新时返回 ErrStaleObject, 更新成功后同步结构体的 version 字段(obj 需为指针)
 func (db *Database) Update(obj any, mustColumn ...string) (affectedRows int64, err error) {
 	segment, binds, err := db.ToSqlUpdate(obj, mustColumn...)
 	if err != nil {
 		return affectedRows, err
 	}
-	return db.Engin.execute(segment, binds...)
+	affectedRows, err = db.Engin.execute(segment, binds...)
+	if err != nil {
+		return
+	}
+	db.forgetCache(obj)
+	if db.dryRun != nil {
+		return
+	}
+	if versionTag, _ := parser.StructToVersion(obj); versionTag != "" {
+		if affectedRows == 0 {
+			return affectedRows, ErrStaleObject
+		}
+		parser.StructVersionIncrement(obj)
+	}
+	return
 }
 
 func (db *Database) Delete(obj any, mustColumn ...string) (affectedRows int64, err error) {
@@ -312,7 +544,10 @@ func (db *Database) Delete(obj any, mustColumn ...string) (affectedRows int64, e
 	if err != nil {
 		return affectedRows, err
 	}
-	return db.Engin.execute(segment, binds...)
+	if affectedRows, err = db.Engin.execute(segment, binds...); err == nil {
+		db.forgetCache(obj)
+	}
+	return
 }
 
 func (db *Database) incDecEach(symbol string, data map[string]any) (affectedRows int64, err error) {
@@ -320,7 +555,10 @@ func (db *Database) incDecEach(symbol string, data map[string]any) (affectedRows
 	if err != nil {
 		return affectedRows, err
 	}
-	return db.Engin.execute(prepare, values...)
+	if affectedRows, err = db.Engin.execute(prepare, values...); err == nil {
+		db.forgetCache(nil)
+	}
+	return
 }
 func (db *Database) incDec(symbol string, column string, steps ...any) (affectedRows int64, err error) {
 	var step any = 1
@@ -348,7 +586,9 @@ func (db *Database) aggregateSingle(bind any, function, column string) error {
 	if err != nil {
 		return err
 	}
-	return db.Engin.QueryRow(prepare, values...).Scan(bind)
+	return db.rememberTo(function, bind, prepare, values, func() error {
+		return db.Engin.queryRow(prepare, values...).Scan(bind)
+	})
 }
 func (db *Database) Max(column string) (res float64, err error) {
 	err = db.aggregateSingle(&res, "max", column)
@@ -397,6 +637,9 @@ func (db *Database) Pluck(column string, keyColumn string) (res map[any]any, err
 }
 func (db *Database) Value(column string) (res any, err error) {
 	first, err := db.First(column)
+	if errors.Is(err, ErrRecordNotFound) {
+		return nil, nil
+	}
 	if err != nil {
 		return res, err
 	}
@@ -407,7 +650,7 @@ func (db *Database) Exists(bind ...any) (b bool, err error) {
 	if err != nil {
 		return b, err
 	}
-	err = db.Engin.QueryRow(prepare, values...).Scan(&b)
+	err = db.Engin.queryRow(prepare, values...).Scan(&b)
 	return
 }
 func (db *Database) DoesntExist(bind ...any) (b bool, err error) {
@@ -416,48 +659,71 @@ func (db *Database) DoesntExist(bind ...any) (b bool, err error) {
 }
 
 func (db *Database) Union(b ...builder.IBuilder) *Database {
+	db = db.builder()
 	db.Context.UnionClause.Union(b...)
 	return db
 }
 
 func (db *Database) UnionAll(b ...builder.IBuilder) *Database {
+	db = db.builder()
 	db.Context.UnionClause.UnionAll(b...)
 	return db
 }
 
 func (db *Database) Truncate(obj ...any) (affectedRows int64, err error) {
+	if err = db.tenantGuard(); err != nil {
+		return
+	}
 	var table string
 	var dbTmp = db
 	if len(obj) > 0 {
-		dbTmp = db.Table(obj[0])
+		dbTmp = db.fork().Table(obj[0])
 	}
 	table, _, err = dbTmp.ToSqlTable()
 	if err != nil {
 		return
 	}
-	return db.Engin.execute(fmt.Sprintf("TRUNCATE TABLE %s", table))
+	if affectedRows, err = db.Engin.execute(fmt.Sprintf("TRUNCATE TABLE %s", table)); err == nil {
+		dbTmp.forgetCache(nil)
+	}
+	return
 }
 
 type TxHandler func() *Database
 
 func (db *Database) Begin() (tx TxHandler, err error) {
+	return db.BeginTx(nil)
+}
+
+// BeginTx 同 Engin.BeginTx
+func (db *Database) BeginTx(opts *sql.TxOptions) (tx TxHandler, err error) {
 	return func() *Database {
-		db.Context = builder.NewContext(db.prefix)
-		return db
-	}, db.Engin.Begin()
+		return db.Reset()
+	}, db.Engin.BeginTx(opts)
 }
 
+// Transaction 同 Engin.Transaction, 设置了重试策略时最外层事务遇到可重试的错误会重新执行所有闭包
 func (db *Database) Transaction(closure ...func(TxHandler) error) error {
-	tx, err := db.Begin()
+	return db.TransactionTx(nil, closure...)
+}
+
+// TransactionTx 同 Transaction, opts 参考 Engin.BeginTx
+func (db *Database) TransactionTx(opts *sql.TxOptions, closure ...func(TxHandler) error) error {
+	if db.tx != nil {
+		return db.transaction(opts, closure...)
+	}
+	return db.withRetry(func() error { return db.transaction(opts, closure...) })
+}
+func (db *Database) transaction(opts *sql.TxOptions, closure ...func(TxHandler) error) error {
+	tx, err := db.BeginTx(opts)
 	if err != nil {
 		return err
 	}
 	for _, v := range closure {
 		err = v(tx)
 		if err != nil {
-			err2 := db.Rollback()
-			if err2 != nil {
-				return err2
+			if err2 := db.Rollback(); err2 != nil {
+				return errors.Join(err, err2)
 			}
 			return err
 		}
@@ -485,7 +751,8 @@ func (db *Database) To(obj any, mustColumn ...string) (err error) {
 		return
 	}
 
-	err = db.queryToBindResult(obj, prepare, binds...)
+	// 与 ToSqlTo 一致以结构体对应的表为准, 缓存才能打上表名 tag
+	err = db.fork().Table(obj).queryToBindResult(obj, prepare, binds...)
 	return
 }
 
@@ -506,7 +773,7 @@ func (db *Database) Bind(obj any) (err error) {
 
 // ListTo 获取指定列的值列表。
 func (db *Database) ListTo(column string, obj any) (err error) {
-	return db.Select(column).toBind(obj)
+	return db.fork().Select(column).toBind(obj)
 }
 
 // PluckTo 从查询结果集中获取键值对列表。
@@ -532,11 +799,11 @@ func (db *Database) PluckTo(column string, keyColumn string, obj any) (err error
 
 // ValueTo 获取指定字段的值,并绑定到给定的变量中
 func (db *Database) ValueTo(column string, obj any) (err error) {
-	prepare, values, err := db.Select(column).ToSql()
+	prepare, values, err := db.fork().Select(column).ToSql()
 	if err != nil {
 		return err
 	}
-	return db.QueryRow(prepare, values...).Scan(obj)
+	return db.Engin.queryRow(prepare, values...).Scan(obj)
 }
 
 // MaxTo 同 Max
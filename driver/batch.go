@@ -0,0 +1,147 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gohouse/gorose/v3/builder"
+	"github.com/gohouse/gorose/v3/parser"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// ToSqlUpdateBatch 批量更新多行不同的值, 每批生成一条语句:
+//
+//	UPDATE t SET a = CASE key WHEN ? THEN ? ... ELSE a END, ... WHERE key IN (?,...)
+//
+// rows 为 []map[string]any 或者结构体切片, keyColumn 为匹配行的列, 为空时使用结构体的主键;
+// 行中没有的列保持原值, 按方言的绑定参数上限(见 dialect.IDialect.MaxBinds)分批
+func (d Driver) ToSqlUpdateBatch(c *builder.Context, rows any, keyColumn string, mustColumn ...string) (sqls []string, binds [][]any, err error) {
+	var ctx = *c
+	datas, keyColumn, err := d.updateBatchRows(&ctx, rows, keyColumn, mustColumn...)
+	if err != nil || len(datas) == 0 {
+		return
+	}
+	var columns []string
+	for _, data := range datas {
+		for column := range data {
+			if column != keyColumn && !slices.Contains(columns, column) {
+				columns = append(columns, column)
+			}
+		}
+	}
+	if len(columns) == 0 {
+		return sqls, binds, errors.New("update batch requires columns besides the key column")
+	}
+	sort.Strings(columns)
+
+	_, whereBinds, err := d.ToSqlWhere(&ctx)
+	if err != nil {
+		return
+	}
+	var limit = d.Dialect.MaxBinds() - len(whereBinds)
+	var start, count int
+	for i, data := range datas {
+		n := updateBatchBinds(data, keyColumn, columns)
+		if n > limit {
+			return sqls, binds, errors.New("update batch row has too many binds for the dialect")
+		}
+		if count+n > limit {
+			if sqls, binds, err = d.appendUpdateBatch(&ctx, datas[start:i], keyColumn, columns, sqls, binds); err != nil {
+				return
+			}
+			start, count = i, 0
+		}
+		count += n
+	}
+	return d.appendUpdateBatch(&ctx, datas[start:], keyColumn, columns, sqls, binds)
+}
+
+// updateBatchBinds 一行占用的绑定参数个数: 每列 WHEN ? THEN ?, 值为 builder.Expr 时按表达式的参数计, 加 IN 中的主键
+func updateBatchBinds(data map[string]any, keyColumn string, columns []string) (n int) {
+	_, keyBinds := placeholder(data[keyColumn])
+	for _, column := range columns {
+		if value, ok := data[column]; ok {
+			_, phBinds := placeholder(value)
+			n += 1 + len(phBinds)
+		}
+	}
+	return n + len(keyBinds)
+}
+
+func (d Driver) appendUpdateBatch(c *builder.Context, datas []map[string]any, keyColumn string, columns []string, sqls []string, binds [][]any) ([]string, [][]any, error) {
+	sql4prepare, values, err := d.toSqlUpdateBatch(c, datas, keyColumn, columns)
+	if err != nil {
+		return sqls, binds, err
+	}
+	return append(sqls, d.Renumber(sql4prepare)), append(binds, values), nil
+}
+
+func (d Driver) toSqlUpdateBatch(c *builder.Context, datas []map[string]any, keyColumn string, columns []string) (sql4prepare string, values []any, err error) {
+	var key = d.Dialect.QuoteIdentifier(keyColumn)
+	var sets []string
+	for _, column := range columns {
+		var whens []string
+		for _, data := range datas {
+			value, ok := data[column]
+			if !ok {
+				continue
+			}
+			ph, phBinds := placeholder(value)
+			whens = append(whens, fmt.Sprintf("WHEN ? THEN %s", ph))
+			values = append(append(values, data[keyColumn]), phBinds...)
+		}
+		var col = d.Dialect.QuoteIdentifier(column)
+		sets = append(sets, fmt.Sprintf("%s = CASE %s %s ELSE %s END", col, key, strings.Join(whens, " "), col))
+	}
+	var keys []any
+	for _, data := range datas {
+		keys = append(keys, data[keyColumn])
+	}
+	var ctx = *c
+	ctx.WhereClause = c.WhereClause.Clone()
+	ctx.WhereClause.WhereIn(keyColumn, keys)
+	return d.toSqlUpdateSets(&ctx, strings.Join(sets, ", "), values)
+}
+
+// updateBatchRows 批量更新的数据, 结构体切片转换为 map, 并确定匹配行的列
+func (d Driver) updateBatchRows(c *builder.Context, rows any, keyColumn string, mustColumn ...string) (datas []map[string]any, key string, err error) {
+	rfv := reflect.Indirect(reflect.ValueOf(rows))
+	if rfv.Kind() != reflect.Slice {
+		return datas, keyColumn, errors.New("update batch rows must be slice of map or struct")
+	}
+	switch rfv.Type().Elem().Kind() {
+	case reflect.Struct:
+		if keyColumn == "" {
+			if pk := parser.Meta(rfv.Type()).Pk; pk != nil {
+				keyColumn = pk.Column
+			}
+		}
+		c.TableClause.Table(rows)
+		datas, err = parser.StructsToInsert(rows, append(slices.Clip(mustColumn), keyColumn)...)
+	case reflect.Map:
+		for i := 0; i < rfv.Len(); i++ {
+			var data = make(map[string]any)
+			iter := rfv.Index(i).MapRange()
+			for iter.Next() {
+				data[iter.Key().String()] = iter.Value().Interface()
+			}
+			datas = append(datas, data)
+		}
+	default:
+		err = errors.New("update batch rows must be slice of map or struct")
+	}
+	if err != nil {
+		return
+	}
+	if keyColumn == "" {
+		return datas, keyColumn, errors.New("update batch key column required")
+	}
+	for _, data := range datas {
+		if data[keyColumn] == nil {
+			return datas, keyColumn, fmt.Errorf("update batch row missing key column %s", keyColumn)
+		}
+	}
+	return datas, keyColumn, nil
+}
@@ -0,0 +1,26 @@
+package dialect
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// onConflict 标准的冲突处理子句, 如 ON CONFLICT ("id") DO UPDATE SET "name"=excluded."name"; 更新时必须指定冲突的唯一键
+func onConflict(ignore bool, keys, updates []string) (string, error) {
+	var target string
+	if len(keys) > 0 {
+		target = fmt.Sprintf(" (%s)", strings.Join(keys, ","))
+	}
+	if ignore {
+		return "ON CONFLICT" + target + " DO NOTHING", nil
+	}
+	if target == "" {
+		return "", errors.New("upsert requires the conflict keys")
+	}
+	var sets []string
+	for _, v := range updates {
+		sets = append(sets, fmt.Sprintf("%s=excluded.%s", v, v))
+	}
+	return fmt.Sprintf("ON CONFLICT%s DO UPDATE SET %s", target, strings.Join(sets, ", ")), nil
+}
@@ -10,9 +10,42 @@ type IDialect interface {
 	//InsertQuery(table string, columns []string, values [][]interface{}) (string, []interface{}) // 批量插入 SQL 生成
 	QuoteIdentifier(identifier string) string // 对标识符（字段名、表名）加引号
 	Upsert() string
+	// InsertConflict 插入冲突时的处理, ignore 为忽略冲突的行, 否则冲突时以插入的值更新 updates 列, keys 为冲突的唯一键, 均已加引号;
+	// insert 为语句开头, 如 INSERT IGNORE, INSERT OR IGNORE, suffix 在语句末尾, 如 ON CONFLICT ("id") DO NOTHING; 不支持时返回错误
+	InsertConflict(ignore bool, keys, updates []string) (insert, suffix string, err error)
 
-	LockInShareMode() string
-	LockForUpdate() string
+	// 行锁, mode 为 share/update/no key update, wait 为空或 nowait/skip locked, of 为已加引号的表名;
+	// LockSuffix 为语句末尾的锁定子句, LockHint 为紧跟表名的锁定提示, 如 mssql 的 WITH (UPDLOCK, READPAST); 不支持的锁定方式返回错误
+	LockSuffix(mode, wait string, of []string) (string, error)
+	LockHint(mode, wait string) (string, error)
+
+	// MutationJoin 带 JOIN 的 UPDATE/DELETE 写法, statement 为 update/delete, 返回:
+	// join 为 UPDATE a JOIN b ON ... SET, target 为 UPDATE/DELETE a ... FROM a JOIN b ON ..., from 为 UPDATE a SET ... FROM b WHERE/DELETE FROM a USING b WHERE, 空为不支持
+	MutationJoin(statement string) string
+	// MutationLimit UPDATE/DELETE 的行数限制, top 紧跟 UPDATE/DELETE(如 TOP (10)), suffix 在语句末尾(如 LIMIT 10), 都为空时不支持; ordered 为是否支持 ORDER BY
+	MutationLimit(limit int) (top, suffix string, ordered bool)
+	// MaxBinds 单条语句的绑定参数上限, 批量语句据此分批
+	MaxBinds() int
+
+	Literal(v any) string // 绑定值对应的字面量, 如 'a''b', NULL, X'0a', 仅用于调试输出
+
+	// DatePart 取日期时间列的部分, part 为 date/time/year/month/day, column 已加引号
+	DatePart(part, column string) string
+
+	// json 字段操作, column 已加引号, path 为 json 路径, 如 meta->address->city 的 [address city]
+	JsonExtract(column string, path []string) string                                                   // 取路径的标量值
+	JsonContains(column string, path []string, value any) (sql4prepare string, binds []any, err error) // 路径下的数组包含 value
+	JsonLength(column string, path []string) string                                                    // 路径下的数组长度
+	JsonSet(expr string, path []string, value any) (sql4prepare string, binds []any, err error)        // 设置路径的值, 返回新的 json 表达式
+
+	// 全文检索, columns 已加引号, mode 为 boolean/natural/expansion/websearch, 空为方言默认; 不支持时返回空
+	FullText(columns []string, query, mode, language string) (sql4prepare string, binds []any)      // 检索条件
+	FullTextScore(columns []string, query, mode, language string) (sql4prepare string, binds []any) // 相关度, 越大越相关
+
+	// 事务保存点语句, name 由调用方生成, 方言负责加引号; 不支持释放保存点的数据库 ReleaseSavePoint 返回空
+	SavePoint(name string) string
+	RollbackTo(name string) string
+	ReleaseSavePoint(name string) string
 }
 
 var dialectMap = map[string]IDialect{}
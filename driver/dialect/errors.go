@@ -0,0 +1,190 @@
+package dialect
+
+import (
+	"database/sql/driver"
+	"errors"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+// IRetryable 方言可选实现, 判断错误是否为可以重试的瞬时错误, 如死锁, 序列化失败
+//
+// 方言包不依赖具体的数据库驱动, 通过错误的 Number/Code 字段或 SQLState() 方法识别, 参考 ErrorNumber/ErrorSQLState
+type IRetryable interface {
+	IsRetryable(err error) bool
+}
+
+// IsRetryable 判断驱动返回的错误是否可以重试: 连接失效(driver.ErrBadConn)或方言判断为瞬时错误
+func IsRetryable(driverName string, err error) bool {
+	if err == nil {
+		return false
+	}
+	if errors.Is(err, driver.ErrBadConn) {
+		return true
+	}
+	if r, ok := GetDialect(driverName).(IRetryable); ok {
+		return r.IsRetryable(err)
+	}
+	return false
+}
+
+// ErrorNumber 获取驱动错误的数字错误码, 如 mysql 的 MySQLError.Number, mssql 的 SQLErrorNumber(), sqlite3 的 Error.Code
+func ErrorNumber(err error) (number int64, ok bool) {
+	walkError(err, func(err error) bool {
+		if e, is := err.(interface{ SQLErrorNumber() int32 }); is {
+			number, ok = int64(e.SQLErrorNumber()), true
+			return true
+		}
+		rv := reflect.Indirect(reflect.ValueOf(err))
+		if rv.Kind() != reflect.Struct {
+			return false
+		}
+		for _, name := range []string{"Number", "Code"} {
+			if f := rv.FieldByName(name); f.IsValid() {
+				switch f.Kind() {
+				case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+					number, ok = f.Int(), true
+					return true
+				case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+					number, ok = int64(f.Uint()), true
+					return true
+				}
+			}
+		}
+		return false
+	})
+	return
+}
+
+// ErrorSQLState 获取驱动错误的 SQLSTATE, 如 postgres 的 40001
+func ErrorSQLState(err error) (state string) {
+	walkError(err, func(err error) bool {
+		if e, ok := err.(interface{ SQLState() string }); ok {
+			state = e.SQLState()
+			return true
+		}
+		rv := reflect.Indirect(reflect.ValueOf(err))
+		if rv.Kind() != reflect.Struct {
+			return false
+		}
+		if f := rv.FieldByName("Code"); f.IsValid() && f.Kind() == reflect.String {
+			state = f.String()
+			return true
+		}
+		return false
+	})
+	return
+}
+
+// walkError 按 errors.As 的顺序深度优先遍历错误链, 包括 errors.Join 等 Unwrap() []error 的错误, fn 返回 true 时停止
+func walkError(err error, fn func(err error) bool) bool {
+	if err == nil {
+		return false
+	}
+	if fn(err) {
+		return true
+	}
+	switch e := err.(type) {
+	case interface{ Unwrap() error }:
+		return walkError(e.Unwrap(), fn)
+	case interface{ Unwrap() []error }:
+		for _, err := range e.Unwrap() {
+			if walkError(err, fn) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// errorContains 错误信息中是否包含任意一个关键字, 用于无法获取错误码的驱动
+func errorContains(err error, keywords ...string) bool {
+	var msg = err.Error()
+	for _, keyword := range keywords {
+		if strings.Contains(msg, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// 归一化的驱动错误类型, 通过 errors.Is(err, ErrDuplicateKey) 判断
+var (
+	ErrDuplicateKey = errors.New("duplicate key")
+	ErrForeignKey   = errors.New("foreign key violation")
+	ErrNotNull      = errors.New("not null violation")
+	ErrDeadlock     = errors.New("deadlock")
+)
+
+// DbError 归一化后的驱动错误, errors.Is 匹配 Kind, errors.As 可以继续获取原始的驱动错误
+type DbError struct {
+	Kind       error  // ErrDuplicateKey/ErrForeignKey/ErrNotNull/ErrDeadlock
+	Constraint string // 违反的约束名(非空约束为字段名), 无法获取时为空
+	Err        error  // 原始的驱动错误
+}
+
+func (e *DbError) Error() string { return e.Err.Error() }
+func (e *DbError) Unwrap() error { return e.Err }
+func (e *DbError) Is(target error) bool {
+	return target == e.Kind
+}
+
+// ErrorClassifier 识别驱动错误的类型, 无法识别时 kind 返回 nil
+type ErrorClassifier func(err error) (kind error, constraint string)
+
+var classifierMap = map[string]ErrorClassifier{}
+
+// RegisterErrorClassifier 注册驱动的错误识别, 与 Register 一起使用, 内置的方言已经注册
+func RegisterErrorClassifier(driver string, classifier ErrorClassifier) {
+	dialectLock.Lock()
+	defer dialectLock.Unlock()
+	classifierMap[driver] = classifier
+}
+
+// ClassifyError 将驱动错误包装为 *DbError, 无法识别的错误原样返回
+func ClassifyError(driver string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var dbErr *DbError
+	if errors.As(err, &dbErr) {
+		return err
+	}
+	dialectLock.RLock()
+	classifier := classifierMap[driver]
+	dialectLock.RUnlock()
+	if classifier == nil {
+		return err
+	}
+	if kind, constraint := classifier(err); kind != nil {
+		return &DbError{Kind: kind, Constraint: constraint, Err: err}
+	}
+	return err
+}
+
+// ErrorField 获取驱动错误的字符串字段, 如 postgres 的 ConstraintName, 依次尝试 names
+func ErrorField(err error, names ...string) (field string) {
+	walkError(err, func(err error) bool {
+		rv := reflect.Indirect(reflect.ValueOf(err))
+		if rv.Kind() != reflect.Struct {
+			return false
+		}
+		for _, name := range names {
+			if f := rv.FieldByName(name); f.IsValid() && f.Kind() == reflect.String && f.String() != "" {
+				field = f.String()
+				return true
+			}
+		}
+		return false
+	})
+	return
+}
+
+// errorSubmatch 从错误信息中提取正则的第一个分组
+func errorSubmatch(err error, re *regexp.Regexp) string {
+	if m := re.FindStringSubmatch(err.Error()); m != nil {
+		return m[1]
+	}
+	return ""
+}
@@ -0,0 +1,19 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// fullTextEach 每列分别检索, 以 OR 连接, 如 (a MATCH ? OR b MATCH ?), format 中的 %s 为列名
+func fullTextEach(columns []string, query string, format string) (sql4prepare string, binds []any) {
+	var tmp []string
+	for _, column := range columns {
+		tmp = append(tmp, fmt.Sprintf(format, column))
+		binds = append(binds, query)
+	}
+	if len(tmp) == 1 {
+		return tmp[0], binds
+	}
+	return "(" + strings.Join(tmp, " OR ") + ")", binds
+}
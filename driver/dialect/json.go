@@ -0,0 +1,62 @@
+package dialect
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// jsonPath 标准 json 路径, 数字为数组下标, 如 [address city] => $."address"."city", [tags 0] => $."tags"[0]
+func jsonPath(path []string) string {
+	var b strings.Builder
+	b.WriteString("$")
+	for _, key := range path {
+		if isJsonIndex(key) {
+			b.WriteString("[" + key + "]")
+		} else {
+			b.WriteString(`."` + strings.ReplaceAll(key, `"`, `\"`) + `"`)
+		}
+	}
+	return b.String()
+}
+
+// isJsonIndex 路径中的数字作为数组下标
+func isJsonIndex(key string) bool {
+	if key == "" {
+		return false
+	}
+	for i := 0; i < len(key); i++ {
+		if key[i] < '0' || key[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// jsonComposite 值是否需要作为 json 对象/数组写入, 如 map, slice, struct; []byte, time.Time 和 driver.Valuer 除外
+func jsonComposite(v any) bool {
+	if _, ok := v.(driver.Valuer); ok {
+		return false
+	}
+	switch v.(type) {
+	case nil, []byte, time.Time:
+		return false
+	}
+	switch reflect.Indirect(reflect.ValueOf(v)).Kind() {
+	case reflect.Map, reflect.Slice, reflect.Array, reflect.Struct:
+		return true
+	}
+	return false
+}
+
+// jsonMarshal 值的 json 文本
+func jsonMarshal(v any) (string, error) {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", fmt.Errorf("json marshal value of %T: %w", v, err)
+	}
+	return string(b), nil
+}
@@ -0,0 +1,85 @@
+package dialect
+
+import (
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// literalFormat 各数据库字面量的写法, 用于 Literal(), 仅供调试输出使用, 不能用于拼接执行的 sql
+type literalFormat struct {
+	quote  func(s string) string    // 字符串
+	bytes  func(b []byte) string    // 二进制, 十六进制表示
+	time   func(t time.Time) string // 时间
+	true_  string
+	false_ string
+}
+
+var (
+	literalQuoteStandard = func(s string) string { return "'" + strings.ReplaceAll(s, "'", "''") + "'" }
+	literalQuoteMySQL    = func(s string) string {
+		return "'" + strings.NewReplacer(`\`, `\\`, "'", `\'`, "\x00", `\0`, "\n", `\n`, "\r", `\r`, "\x1a", `\Z`).Replace(s) + "'"
+	}
+	literalBytesX = func(b []byte) string { return "X'" + hex.EncodeToString(b) + "'" }
+)
+
+// literal 将绑定值转换为 sql 字面量
+func literal(v any, f literalFormat) string {
+	if valuer, ok := v.(driver.Valuer); ok {
+		if rfv := reflect.ValueOf(v); rfv.Kind() == reflect.Ptr && rfv.IsNil() {
+			return "NULL"
+		}
+		value, err := valuer.Value()
+		if err != nil {
+			return f.quote(fmt.Sprint(v))
+		}
+		return literal(value, f)
+	}
+	switch val := v.(type) {
+	case nil:
+		return "NULL"
+	case string:
+		return f.quote(val)
+	case []byte:
+		if val == nil {
+			return "NULL"
+		}
+		return f.bytes(val)
+	case time.Time:
+		return f.time(val)
+	case bool:
+		if val {
+			return f.true_
+		}
+		return f.false_
+	case float32:
+		return strconv.FormatFloat(float64(val), 'g', -1, 32)
+	case float64:
+		return strconv.FormatFloat(val, 'g', -1, 64)
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprint(val)
+	}
+	rfv := reflect.ValueOf(v)
+	switch rfv.Kind() {
+	case reflect.Ptr:
+		if rfv.IsNil() {
+			return "NULL"
+		}
+		return literal(rfv.Elem().Interface(), f)
+	case reflect.String:
+		return f.quote(rfv.String())
+	case reflect.Bool:
+		return literal(rfv.Bool(), f)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rfv.Int(), 10)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rfv.Uint(), 10)
+	case reflect.Float32, reflect.Float64:
+		return literal(rfv.Float(), f)
+	}
+	return f.quote(fmt.Sprint(v))
+}
@@ -0,0 +1,23 @@
+package dialect
+
+import (
+	"fmt"
+	"strings"
+)
+
+// errLock 方言不支持的锁定方式, 返回错误而不是静默忽略, 避免调用方误以为已加锁
+func errLock(dialect, lock string) error {
+	return fmt.Errorf("%s is not supported by %s", lock, dialect)
+}
+
+// lockSuffix 标准的行锁子句, 如 FOR UPDATE OF "a" SKIP LOCKED
+func lockSuffix(mode, wait string, of []string) string {
+	var sql = "FOR " + strings.ToUpper(mode)
+	if len(of) > 0 {
+		sql += " OF " + strings.Join(of, ", ")
+	}
+	if wait != "" {
+		sql += " " + strings.ToUpper(wait)
+	}
+	return sql
+}
@@ -1,6 +1,13 @@
 package dialect
 
-import "fmt"
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
 
 type MsSQLDialect struct {
 	placeHolderIndex int
@@ -8,6 +15,7 @@ type MsSQLDialect struct {
 
 func init() {
 	Register("mssql", &MsSQLDialect{})
+	RegisterErrorClassifier("mssql", mssqlClassifyError)
 }
 
 func (d *MsSQLDialect) New() IDialect {
@@ -35,12 +43,151 @@ func (d *MsSQLDialect) QuoteIdentifier(identifier string) string {
 	return fmt.Sprintf("[%s]", identifier)
 }
 
+// DatePart CAST(col AS DATE), CAST(col AS TIME), DATEPART(year, col) 等
+func (d *MsSQLDialect) DatePart(part, column string) string {
+	switch part {
+	case "date", "time":
+		return fmt.Sprintf("CAST(%s AS %s)", column, strings.ToUpper(part))
+	default:
+		return fmt.Sprintf("DATEPART(%s, %s)", part, column)
+	}
+}
+
+// JsonExtract JSON_VALUE(col, '$."a"')
+func (d *MsSQLDialect) JsonExtract(column string, path []string) string {
+	return fmt.Sprintf("JSON_VALUE(%s, %s)", column, literalQuoteStandard(jsonPath(path)))
+}
+
+// JsonContains ? IN (SELECT [value] FROM OPENJSON(col, '$."a"')), value 为标量
+func (d *MsSQLDialect) JsonContains(column string, path []string, value any) (string, []any, error) {
+	return fmt.Sprintf("? IN (SELECT [value] FROM OPENJSON(%s, %s))", column, literalQuoteStandard(jsonPath(path))), []any{value}, nil
+}
+
+// JsonLength (SELECT COUNT(*) FROM OPENJSON(col, '$."a"'))
+func (d *MsSQLDialect) JsonLength(column string, path []string) string {
+	return fmt.Sprintf("(SELECT COUNT(*) FROM OPENJSON(%s, %s))", column, literalQuoteStandard(jsonPath(path)))
+}
+
+// JsonSet JSON_MODIFY(col, '$."a"', ?), 对象和数组以 JSON_QUERY(?) 写入
+func (d *MsSQLDialect) JsonSet(expr string, path []string, value any) (string, []any, error) {
+	if jsonComposite(value) {
+		j, err := jsonMarshal(value)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("JSON_MODIFY(%s, %s, JSON_QUERY(?))", expr, literalQuoteStandard(jsonPath(path))), []any{j}, nil
+	}
+	return fmt.Sprintf("JSON_MODIFY(%s, %s, ?)", expr, literalQuoteStandard(jsonPath(path))), []any{value}, nil
+}
+
+// FullText CONTAINS((a, b), ?), natural/expansion 为 FREETEXT((a, b), ?)
+func (d *MsSQLDialect) FullText(columns []string, query, mode, language string) (string, []any) {
+	var fn = "CONTAINS"
+	if mode == "natural" || mode == "expansion" {
+		fn = "FREETEXT"
+	}
+	var column = columns[0]
+	if len(columns) > 1 {
+		column = "(" + strings.Join(columns, ", ") + ")"
+	}
+	if language == "" {
+		return fmt.Sprintf("%s(%s, ?)", fn, column), []any{query}
+	}
+	return fmt.Sprintf("%s(%s, ?, LANGUAGE %s)", fn, column, literalQuoteStandard(language)), []any{query}
+}
+
+// FullTextScore 相关度需要 CONTAINSTABLE 关联查询, 不支持
+func (d *MsSQLDialect) FullTextScore(columns []string, query, mode, language string) (string, []any) {
+	return "", nil
+}
+
 func (d *MsSQLDialect) Upsert() string {
 	return "MERGE INTO"
 }
 
-func (d *MsSQLDialect) LockInShareMode() string { return "" }
+// InsertConflict 需要 MERGE 语句, 不支持
+func (d *MsSQLDialect) InsertConflict(ignore bool, keys, updates []string) (string, string, error) {
+	return "", "", errors.New("insert ignore and upsert are not supported by mssql, use MERGE")
+}
+
+// LockSuffix mssql 的锁为表提示, 见 LockHint
+func (d *MsSQLDialect) LockSuffix(mode, wait string, of []string) (string, error) { return "", nil }
 
-//func (d *MsSQLDialect) LockInShareMode() string { return "WITH (HOLDLOCK)" }
+// LockHint 紧跟表名的锁提示, 如 FROM [jobs] WITH (UPDLOCK, READPAST); 不支持 share
+func (d *MsSQLDialect) LockHint(mode, wait string) (string, error) {
+	if mode == "share" {
+		return "", errLock("mssql", "share lock")
+	}
+	switch wait {
+	case "skip locked":
+		return "WITH (UPDLOCK, READPAST)", nil
+	case "nowait":
+		return "WITH (UPDLOCK, ROWLOCK, NOWAIT)", nil
+	default:
+		return "WITH (UPDLOCK, ROWLOCK)", nil
+	}
+}
+
+// MutationJoin UPDATE a SET ... FROM a JOIN b ON ..., DELETE a FROM a JOIN b ON ...
+func (d *MsSQLDialect) MutationJoin(statement string) string { return "target" }
+
+// MutationLimit UPDATE TOP (n), 不支持 ORDER BY
+func (d *MsSQLDialect) MutationLimit(limit int) (top, suffix string, ordered bool) {
+	if limit > 0 {
+		top = fmt.Sprintf("TOP (%d)", limit)
+	}
+	return top, "", false
+}
+
+// MaxBinds 上限为 2100, sp_executesql 自身的 @stmt, @params 也计入, 留出余量
+func (d *MsSQLDialect) MaxBinds() int { return 2000 }
+
+func (d *MsSQLDialect) SavePoint(name string) string {
+	return "SAVE TRANSACTION " + d.QuoteIdentifier(name)
+}
+func (d *MsSQLDialect) RollbackTo(name string) string {
+	return "ROLLBACK TRANSACTION " + d.QuoteIdentifier(name)
+}
 
-func (d *MsSQLDialect) LockForUpdate() string { return "WITH (ROWLOCK)" }
+// ReleaseSavePoint sql server 没有释放保存点的语句, 保存点随事务结束
+func (d *MsSQLDialect) ReleaseSavePoint(string) string { return "" }
+
+var mssqlLiteral = literalFormat{
+	quote:  func(s string) string { return "N" + literalQuoteStandard(s) },
+	bytes:  func(b []byte) string { return "0x" + hex.EncodeToString(b) },
+	time:   func(t time.Time) string { return "'" + t.Format("2006-01-02T15:04:05.999") + "'" },
+	true_:  "1",
+	false_: "0",
+}
+
+func (d *MsSQLDialect) Literal(v any) string { return literal(v, mssqlLiteral) }
+
+var (
+	mssqlConstraintRegexp = regexp.MustCompile(`constraint ['"]([^'"]+)['"]`)
+	mssqlColumnRegexp     = regexp.MustCompile(`column '([^']+)'`)
+)
+
+// mssqlClassifyError 2627/2601: 唯一键冲突, 547: 外键, 515: 非空, 1205: 死锁
+func mssqlClassifyError(err error) (kind error, constraint string) {
+	number, ok := ErrorNumber(err)
+	if !ok {
+		return
+	}
+	switch number {
+	case 2627, 2601:
+		return ErrDuplicateKey, errorSubmatch(err, mssqlConstraintRegexp)
+	case 547:
+		return ErrForeignKey, errorSubmatch(err, mssqlConstraintRegexp)
+	case 515:
+		return ErrNotNull, errorSubmatch(err, mssqlColumnRegexp)
+	case 1205:
+		return ErrDeadlock, ""
+	}
+	return
+}
+
+// IsRetryable 1205: 死锁, 3960: 快照隔离更新冲突
+func (d *MsSQLDialect) IsRetryable(err error) bool {
+	number, ok := ErrorNumber(err)
+	return ok && (number == 1205 || number == 3960)
+}
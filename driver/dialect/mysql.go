@@ -1,11 +1,17 @@
 package dialect
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
 
 type MySQLDialect struct{}
 
 func init() {
 	Register("mysql", &MySQLDialect{})
+	RegisterErrorClassifier("mysql", mysqlClassifyError)
 }
 
 func (d *MySQLDialect) New() IDialect {
@@ -34,7 +40,156 @@ func (d *MySQLDialect) QuoteIdentifier(identifier string) string {
 	return fmt.Sprintf("`%s`", identifier)
 }
 
+// DatePart DATE(col), TIME(col), YEAR(col), MONTH(col), DAY(col)
+func (d *MySQLDialect) DatePart(part, column string) string {
+	return fmt.Sprintf("%s(%s)", strings.ToUpper(part), column)
+}
+
+// JsonExtract JSON_UNQUOTE(JSON_EXTRACT(col, '$."a"'))
+func (d *MySQLDialect) JsonExtract(column string, path []string) string {
+	return fmt.Sprintf("JSON_UNQUOTE(JSON_EXTRACT(%s, %s))", column, literalQuoteMySQL(jsonPath(path)))
+}
+
+// JsonContains JSON_CONTAINS(col, ?, '$."a"'), value 以 json 绑定
+func (d *MySQLDialect) JsonContains(column string, path []string, value any) (string, []any, error) {
+	j, err := jsonMarshal(value)
+	if err != nil {
+		return "", nil, err
+	}
+	if len(path) == 0 {
+		return fmt.Sprintf("JSON_CONTAINS(%s, ?)", column), []any{j}, nil
+	}
+	return fmt.Sprintf("JSON_CONTAINS(%s, ?, %s)", column, literalQuoteMySQL(jsonPath(path))), []any{j}, nil
+}
+
+// JsonLength JSON_LENGTH(col, '$."a"')
+func (d *MySQLDialect) JsonLength(column string, path []string) string {
+	if len(path) == 0 {
+		return fmt.Sprintf("JSON_LENGTH(%s)", column)
+	}
+	return fmt.Sprintf("JSON_LENGTH(%s, %s)", column, literalQuoteMySQL(jsonPath(path)))
+}
+
+// JsonSet JSON_SET(col, '$."a"', ?), 对象和数组以 CAST(? AS JSON) 写入
+func (d *MySQLDialect) JsonSet(expr string, path []string, value any) (string, []any, error) {
+	if jsonComposite(value) {
+		j, err := jsonMarshal(value)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("JSON_SET(%s, %s, CAST(? AS JSON))", expr, literalQuoteMySQL(jsonPath(path))), []any{j}, nil
+	}
+	return fmt.Sprintf("JSON_SET(%s, %s, ?)", expr, literalQuoteMySQL(jsonPath(path))), []any{value}, nil
+}
+
+// FullText MATCH(a, b) AGAINST(? IN BOOLEAN MODE), 默认为 boolean 模式
+func (d *MySQLDialect) FullText(columns []string, query, mode, language string) (string, []any) {
+	var modifier = " IN BOOLEAN MODE"
+	switch mode {
+	case "natural":
+		modifier = " IN NATURAL LANGUAGE MODE"
+	case "expansion":
+		modifier = " WITH QUERY EXPANSION"
+	}
+	return fmt.Sprintf("MATCH(%s) AGAINST(?%s)", strings.Join(columns, ", "), modifier), []any{query}
+}
+
+// FullTextScore 同 FullText, MATCH ... AGAINST 在 select 中即为相关度
+func (d *MySQLDialect) FullTextScore(columns []string, query, mode, language string) (string, []any) {
+	return d.FullText(columns, query, mode, language)
+}
+
 func (d *MySQLDialect) Upsert() string { return "ON DUPLICATE KEY UPDATE" }
 
-func (d *MySQLDialect) LockInShareMode() string { return "LOCK IN SHARE MODE" }
-func (d *MySQLDialect) LockForUpdate() string   { return "FOR UPDATE" }
+// InsertConflict INSERT IGNORE, ON DUPLICATE KEY UPDATE `a`=VALUES(`a`), 由唯一索引和主键判断冲突, 不需要 keys
+func (d *MySQLDialect) InsertConflict(ignore bool, keys, updates []string) (string, string, error) {
+	if ignore {
+		return "INSERT IGNORE", "", nil
+	}
+	var sets []string
+	for _, v := range updates {
+		sets = append(sets, fmt.Sprintf("%s=VALUES(%s)", v, v))
+	}
+	return "INSERT", fmt.Sprintf("%s %s", d.Upsert(), strings.Join(sets, ", ")), nil
+}
+
+// LockSuffix mysql 8 支持 FOR SHARE, OF, NOWAIT, SKIP LOCKED; no key update 按 FOR UPDATE 处理
+func (d *MySQLDialect) LockSuffix(mode, wait string, of []string) (string, error) {
+	if mode == "no key update" {
+		mode = "update"
+	}
+	if mode == "share" && wait == "" && len(of) == 0 {
+		return "LOCK IN SHARE MODE", nil
+	}
+	return lockSuffix(mode, wait, of), nil
+}
+func (d *MySQLDialect) LockHint(mode, wait string) (string, error) { return "", nil }
+
+func (d *MySQLDialect) SavePoint(name string) string {
+	return "SAVEPOINT " + d.QuoteIdentifier(name)
+}
+
+// MutationJoin UPDATE a JOIN b ON ... SET, DELETE a FROM a JOIN b ON ...
+func (d *MySQLDialect) MutationJoin(statement string) string {
+	if statement == "delete" {
+		return "target"
+	}
+	return "join"
+}
+
+// MutationLimit 单表 UPDATE/DELETE 支持 ORDER BY ... LIMIT n
+func (d *MySQLDialect) MutationLimit(limit int) (top, suffix string, ordered bool) {
+	if limit > 0 {
+		suffix = fmt.Sprintf("LIMIT %d", limit)
+	}
+	return "", suffix, true
+}
+
+func (d *MySQLDialect) MaxBinds() int { return 65535 }
+func (d *MySQLDialect) RollbackTo(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + d.QuoteIdentifier(name)
+}
+func (d *MySQLDialect) ReleaseSavePoint(name string) string {
+	return "RELEASE SAVEPOINT " + d.QuoteIdentifier(name)
+}
+
+var mysqlLiteral = literalFormat{
+	quote:  literalQuoteMySQL,
+	bytes:  literalBytesX,
+	time:   func(t time.Time) string { return "'" + t.Format("2006-01-02 15:04:05.999999") + "'" },
+	true_:  "TRUE",
+	false_: "FALSE",
+}
+
+func (d *MySQLDialect) Literal(v any) string { return literal(v, mysqlLiteral) }
+
+var (
+	mysqlKeyRegexp        = regexp.MustCompile("for key '([^']+)'")
+	mysqlConstraintRegexp = regexp.MustCompile("CONSTRAINT `([^`]+)`")
+	mysqlColumnRegexp     = regexp.MustCompile("(?:Column|Field) '([^']+)'")
+)
+
+// mysqlClassifyError 1062: 唯一键冲突, 1451/1452: 外键, 1048/1364: 非空, 1213: 死锁
+func mysqlClassifyError(err error) (kind error, constraint string) {
+	number, ok := ErrorNumber(err)
+	if !ok {
+		return
+	}
+	switch number {
+	case 1062:
+		return ErrDuplicateKey, errorSubmatch(err, mysqlKeyRegexp)
+	case 1451, 1452:
+		return ErrForeignKey, errorSubmatch(err, mysqlConstraintRegexp)
+	case 1048, 1364:
+		return ErrNotNull, errorSubmatch(err, mysqlColumnRegexp)
+	case 1213:
+		return ErrDeadlock, ""
+	}
+	return
+}
+
+// IsRetryable 1213: 死锁, 1205: 锁等待超时
+func (d *MySQLDialect) IsRetryable(err error) bool {
+	number, ok := ErrorNumber(err)
+	return ok && (number == 1213 || number == 1205)
+}
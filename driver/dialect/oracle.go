@@ -1,6 +1,13 @@
 package dialect
 
-import "fmt"
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
 
 type OracleDialect struct {
 	placeHolderIndex int
@@ -8,6 +15,7 @@ type OracleDialect struct {
 
 func init() {
 	Register("oracle", &OracleDialect{})
+	RegisterErrorClassifier("oracle", oracleClassifyError)
 }
 
 func (d *OracleDialect) New() IDialect {
@@ -35,9 +43,129 @@ func (d *OracleDialect) QuoteIdentifier(identifier string) string {
 	return fmt.Sprintf("\"%s\"", identifier)
 }
 
+// DatePart TRUNC(col), TO_CHAR(col, 'HH24:MI:SS'), EXTRACT(YEAR FROM col) 等
+func (d *OracleDialect) DatePart(part, column string) string {
+	switch part {
+	case "date":
+		return fmt.Sprintf("TRUNC(%s)", column)
+	case "time":
+		return fmt.Sprintf("TO_CHAR(%s, 'HH24:MI:SS')", column)
+	default:
+		return fmt.Sprintf("EXTRACT(%s FROM %s)", strings.ToUpper(part), column)
+	}
+}
+
+// JsonExtract JSON_VALUE(col, '$."a"')
+func (d *OracleDialect) JsonExtract(column string, path []string) string {
+	return fmt.Sprintf("JSON_VALUE(%s, %s)", column, literalQuoteStandard(jsonPath(path)))
+}
+
+// JsonContains JSON_EXISTS(col, '$."a"[*]?(@ == $v)' PASSING ? AS "v"), value 为标量
+func (d *OracleDialect) JsonContains(column string, path []string, value any) (string, []any, error) {
+	return fmt.Sprintf(`JSON_EXISTS(%s, %s PASSING ? AS "v")`, column, literalQuoteStandard(jsonPath(path)+"[*]?(@ == $v)")), []any{value}, nil
+}
+
+// JsonLength JSON_VALUE(col, '$."a".size()')
+func (d *OracleDialect) JsonLength(column string, path []string) string {
+	return fmt.Sprintf("JSON_VALUE(%s, %s)", column, literalQuoteStandard(jsonPath(path)+".size()"))
+}
+
+// JsonSet JSON_TRANSFORM(col, SET '$."a"' = ?), 对象和数组以 ? FORMAT JSON 写入
+func (d *OracleDialect) JsonSet(expr string, path []string, value any) (string, []any, error) {
+	if jsonComposite(value) {
+		j, err := jsonMarshal(value)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("JSON_TRANSFORM(%s, SET %s = ? FORMAT JSON)", expr, literalQuoteStandard(jsonPath(path))), []any{j}, nil
+	}
+	return fmt.Sprintf("JSON_TRANSFORM(%s, SET %s = ?)", expr, literalQuoteStandard(jsonPath(path))), []any{value}, nil
+}
+
+// FullText CONTAINS(a, ?) > 0, 多列以 OR 连接
+func (d *OracleDialect) FullText(columns []string, query, mode, language string) (string, []any) {
+	return fullTextEach(columns, query, "CONTAINS(%s, ?) > 0")
+}
+
+// FullTextScore 相关度需要 CONTAINS 的标签和 SCORE(label), 不支持
+func (d *OracleDialect) FullTextScore(columns []string, query, mode, language string) (string, []any) {
+	return "", nil
+}
+
 func (d *OracleDialect) Upsert() string {
 	return "MERGE INTO"
 }
 
-func (d *OracleDialect) LockInShareMode() string { return "" }
-func (d *OracleDialect) LockForUpdate() string   { return "FOR UPDATE" }
+// InsertConflict 需要 MERGE 语句, 不支持
+func (d *OracleDialect) InsertConflict(ignore bool, keys, updates []string) (string, string, error) {
+	return "", "", errors.New("insert ignore and upsert are not supported by oracle, use MERGE")
+}
+
+// LockSuffix 只支持 FOR UPDATE, no key update 按 FOR UPDATE 处理; oracle 的 FOR UPDATE OF 为锁定的列而不是表, 不支持 LockOf
+func (d *OracleDialect) LockSuffix(mode, wait string, of []string) (string, error) {
+	if mode == "share" {
+		return "", errLock("oracle", "share lock")
+	}
+	if len(of) > 0 {
+		return "", errLock("oracle", "lock of tables")
+	}
+	return lockSuffix("update", wait, nil), nil
+}
+func (d *OracleDialect) LockHint(mode, wait string) (string, error) { return "", nil }
+
+func (d *OracleDialect) SavePoint(name string) string {
+	return "SAVEPOINT " + d.QuoteIdentifier(name)
+}
+
+// MutationJoin 不支持
+func (d *OracleDialect) MutationJoin(statement string) string { return "" }
+
+// MutationLimit 不支持
+func (d *OracleDialect) MutationLimit(limit int) (top, suffix string, ordered bool) {
+	return "", "", false
+}
+
+func (d *OracleDialect) MaxBinds() int { return 65535 }
+func (d *OracleDialect) RollbackTo(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + d.QuoteIdentifier(name)
+}
+
+// ReleaseSavePoint oracle 没有释放保存点的语句, 保存点随事务结束
+func (d *OracleDialect) ReleaseSavePoint(string) string { return "" }
+
+var oracleLiteral = literalFormat{
+	quote:  literalQuoteStandard,
+	bytes:  func(b []byte) string { return "HEXTORAW('" + hex.EncodeToString(b) + "')" },
+	time:   func(t time.Time) string { return "TIMESTAMP '" + t.Format("2006-01-02 15:04:05.999999") + "'" },
+	true_:  "1",
+	false_: "0",
+}
+
+func (d *OracleDialect) Literal(v any) string { return literal(v, oracleLiteral) }
+
+var oracleConstraintRegexp = regexp.MustCompile(`constraint \(([^)]+)\)|into \(([^)]+)\)`)
+
+// oracleClassifyError ORA-00001: 唯一键冲突, ORA-02291/02292: 外键, ORA-01400: 非空, ORA-00060: 死锁
+func oracleClassifyError(err error) (kind error, constraint string) {
+	switch {
+	case errorContains(err, "ORA-00001"):
+		kind = ErrDuplicateKey
+	case errorContains(err, "ORA-02291", "ORA-02292"):
+		kind = ErrForeignKey
+	case errorContains(err, "ORA-01400"):
+		kind = ErrNotNull
+	case errorContains(err, "ORA-00060"):
+		return ErrDeadlock, ""
+	default:
+		return
+	}
+	if m := oracleConstraintRegexp.FindStringSubmatch(err.Error()); m != nil {
+		constraint = m[1] + m[2]
+	}
+	return
+}
+
+// IsRetryable ORA-00060: 死锁, ORA-08177: 序列化失败
+func (d *OracleDialect) IsRetryable(err error) bool {
+	return errorContains(err, "ORA-00060", "ORA-08177")
+}
@@ -1,7 +1,10 @@
 package dialect
 
 import (
+	"encoding/hex"
 	"fmt"
+	"strings"
+	"time"
 )
 
 type PostgresqlDialect struct {
@@ -10,6 +13,7 @@ type PostgresqlDialect struct {
 
 func init() {
 	Register("postgresql", &PostgresqlDialect{})
+	RegisterErrorClassifier("postgresql", postgresqlClassifyError)
 }
 
 func (d *PostgresqlDialect) New() IDialect {
@@ -68,9 +72,181 @@ func (d *PostgresqlDialect) QuoteIdentifier(identifier string) string {
 	return fmt.Sprintf("\"%s\"", identifier)
 }
 
+// DatePart col::date, col::time, EXTRACT(YEAR FROM col) 等
+func (d *PostgresqlDialect) DatePart(part, column string) string {
+	switch part {
+	case "date", "time":
+		return fmt.Sprintf("%s::%s", column, part)
+	default:
+		return fmt.Sprintf("EXTRACT(%s FROM %s)", strings.ToUpper(part), column)
+	}
+}
+
+// postgresqlJsonPath ->'a'->0 形式的路径
+func postgresqlJsonPath(path []string) string {
+	var b strings.Builder
+	for _, key := range path {
+		if isJsonIndex(key) {
+			b.WriteString("->" + key)
+		} else {
+			b.WriteString("->" + literalQuoteStandard(key))
+		}
+	}
+	return b.String()
+}
+
+// JsonExtract col->'a'->>'b'
+func (d *PostgresqlDialect) JsonExtract(column string, path []string) string {
+	if len(path) == 0 {
+		return column
+	}
+	var last = path[len(path)-1]
+	if !isJsonIndex(last) {
+		last = literalQuoteStandard(last)
+	}
+	return fmt.Sprintf("%s%s->>%s", column, postgresqlJsonPath(path[:len(path)-1]), last)
+}
+
+// JsonContains (col->'a')::jsonb @> ?::jsonb, value 以 json 绑定
+func (d *PostgresqlDialect) JsonContains(column string, path []string, value any) (string, []any, error) {
+	j, err := jsonMarshal(value)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("(%s%s)::jsonb @> ?::jsonb", column, postgresqlJsonPath(path)), []any{j}, nil
+}
+
+// JsonLength jsonb_array_length((col->'a')::jsonb)
+func (d *PostgresqlDialect) JsonLength(column string, path []string) string {
+	return fmt.Sprintf("jsonb_array_length((%s%s)::jsonb)", column, postgresqlJsonPath(path))
+}
+
+// JsonSet jsonb_set(col::jsonb, '{a,b}', ?::jsonb), value 以 json 绑定
+func (d *PostgresqlDialect) JsonSet(expr string, path []string, value any) (string, []any, error) {
+	j, err := jsonMarshal(value)
+	if err != nil {
+		return "", nil, err
+	}
+	return fmt.Sprintf("jsonb_set(%s::jsonb, %s, ?::jsonb)", expr, literalQuoteStandard(postgresqlTextArray(path))), []any{j}, nil
+}
+
+// postgresqlTextArray text[] 字面量, 含 , { } " \ 空白的元素加双引号转义, 如 [a b,c] => {a,"b,c"}
+func postgresqlTextArray(elems []string) string {
+	var tmp = make([]string, len(elems))
+	for i, v := range elems {
+		if v == "" || strings.EqualFold(v, "null") || strings.ContainsAny(v, ",{}\"\\ \t\n\r") {
+			v = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(v) + `"`
+		}
+		tmp[i] = v
+	}
+	return "{" + strings.Join(tmp, ",") + "}"
+}
+
+// postgresqlTsVector to_tsvector('english', coalesce(a, ”) || ' ' || coalesce(b, ”))
+func postgresqlTsVector(columns []string, language string) string {
+	var document = columns[0]
+	if len(columns) > 1 {
+		var tmp []string
+		for _, column := range columns {
+			tmp = append(tmp, fmt.Sprintf("coalesce(%s, '')", column))
+		}
+		document = strings.Join(tmp, " || ' ' || ")
+	}
+	if language == "" {
+		return fmt.Sprintf("to_tsvector(%s)", document)
+	}
+	return fmt.Sprintf("to_tsvector(%s, %s)", literalQuoteStandard(language), document)
+}
+
+// postgresqlTsQuery plainto_tsquery('english', ?), boolean 和 websearch 为 websearch_to_tsquery,
+// 支持 "+golang -php", "go or rust", "\"query builder\"" 等写法, 与 mysql 的 boolean 模式相近
+func postgresqlTsQuery(mode, language string) string {
+	var fn = "plainto_tsquery"
+	switch mode {
+	case "boolean", "websearch":
+		fn = "websearch_to_tsquery"
+	}
+	if language == "" {
+		return fn + "(?)"
+	}
+	return fmt.Sprintf("%s(%s, ?)", fn, literalQuoteStandard(language))
+}
+
+// FullText to_tsvector(a) @@ plainto_tsquery(?)
+func (d *PostgresqlDialect) FullText(columns []string, query, mode, language string) (string, []any) {
+	return fmt.Sprintf("%s @@ %s", postgresqlTsVector(columns, language), postgresqlTsQuery(mode, language)), []any{query}
+}
+
+// FullTextScore ts_rank(to_tsvector(a), plainto_tsquery(?))
+func (d *PostgresqlDialect) FullTextScore(columns []string, query, mode, language string) (string, []any) {
+	return fmt.Sprintf("ts_rank(%s, %s)", postgresqlTsVector(columns, language), postgresqlTsQuery(mode, language)), []any{query}
+}
+
 func (d *PostgresqlDialect) Upsert() string {
 	return "ON CONFLICT DO UPDATE"
 }
 
-func (d *PostgresqlDialect) LockInShareMode() string { return "FOR SHARE" }
-func (d *PostgresqlDialect) LockForUpdate() string   { return "FOR UPDATE" }
+// InsertConflict ON CONFLICT DO NOTHING, ON CONFLICT ("id") DO UPDATE SET "a"=excluded."a"
+func (d *PostgresqlDialect) InsertConflict(ignore bool, keys, updates []string) (string, string, error) {
+	suffix, err := onConflict(ignore, keys, updates)
+	return "INSERT", suffix, err
+}
+
+// LockSuffix FOR SHARE, FOR UPDATE, FOR NO KEY UPDATE 以及 OF, NOWAIT, SKIP LOCKED
+func (d *PostgresqlDialect) LockSuffix(mode, wait string, of []string) (string, error) {
+	return lockSuffix(mode, wait, of), nil
+}
+func (d *PostgresqlDialect) LockHint(mode, wait string) (string, error) { return "", nil }
+
+func (d *PostgresqlDialect) SavePoint(name string) string {
+	return "SAVEPOINT " + d.QuoteIdentifier(name)
+}
+
+// MutationJoin UPDATE a SET ... FROM b WHERE, DELETE FROM a USING b WHERE
+func (d *PostgresqlDialect) MutationJoin(statement string) string { return "from" }
+
+// MutationLimit 不支持
+func (d *PostgresqlDialect) MutationLimit(limit int) (top, suffix string, ordered bool) {
+	return "", "", false
+}
+
+func (d *PostgresqlDialect) MaxBinds() int { return 65535 }
+func (d *PostgresqlDialect) RollbackTo(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + d.QuoteIdentifier(name)
+}
+func (d *PostgresqlDialect) ReleaseSavePoint(name string) string {
+	return "RELEASE SAVEPOINT " + d.QuoteIdentifier(name)
+}
+
+var postgresqlLiteral = literalFormat{
+	quote:  literalQuoteStandard,
+	bytes:  func(b []byte) string { return `'\x` + hex.EncodeToString(b) + "'::bytea" },
+	time:   func(t time.Time) string { return "'" + t.Format("2006-01-02 15:04:05.999999Z07:00") + "'" },
+	true_:  "TRUE",
+	false_: "FALSE",
+}
+
+func (d *PostgresqlDialect) Literal(v any) string { return literal(v, postgresqlLiteral) }
+
+// postgresqlClassifyError 23505: 唯一键冲突, 23503: 外键, 23502: 非空, 40P01: 死锁
+//
+// 约束名取自 lib/pq 的 Error.Constraint 或 pgx 的 PgError.ConstraintName
+func postgresqlClassifyError(err error) (kind error, constraint string) {
+	switch ErrorSQLState(err) {
+	case "23505":
+		return ErrDuplicateKey, ErrorField(err, "ConstraintName", "Constraint")
+	case "23503":
+		return ErrForeignKey, ErrorField(err, "ConstraintName", "Constraint")
+	case "23502":
+		return ErrNotNull, ErrorField(err, "ColumnName", "Column")
+	case "40P01":
+		return ErrDeadlock, ""
+	}
+	return
+}
+
+// IsRetryable 40001: 序列化失败, 40P01: 死锁
+func (d *PostgresqlDialect) IsRetryable(err error) bool {
+	state := ErrorSQLState(err)
+	return state == "40001" || state == "40P01"
+}
@@ -1,11 +1,16 @@
 package dialect
 
-import "fmt"
+import (
+	"fmt"
+	"regexp"
+	"time"
+)
 
 type SQLite3Dialect struct{}
 
 func init() {
 	Register("sqlite3", &SQLite3Dialect{})
+	RegisterErrorClassifier("sqlite3", sqlite3ClassifyError)
 }
 
 func (d *SQLite3Dialect) New() IDialect {
@@ -34,9 +39,142 @@ func (d *SQLite3Dialect) QuoteIdentifier(identifier string) string {
 	return fmt.Sprintf("\"%s\"", identifier)
 }
 
+// DatePart date(col), time(col), CAST(strftime('%Y', col) AS INTEGER) 等
+func (d *SQLite3Dialect) DatePart(part, column string) string {
+	switch part {
+	case "year":
+		return fmt.Sprintf("CAST(strftime('%%Y', %s) AS INTEGER)", column)
+	case "month":
+		return fmt.Sprintf("CAST(strftime('%%m', %s) AS INTEGER)", column)
+	case "day":
+		return fmt.Sprintf("CAST(strftime('%%d', %s) AS INTEGER)", column)
+	default:
+		return fmt.Sprintf("%s(%s)", part, column)
+	}
+}
+
+// JsonExtract json_extract(col, '$."a"')
+func (d *SQLite3Dialect) JsonExtract(column string, path []string) string {
+	return fmt.Sprintf("json_extract(%s, %s)", column, literalQuoteStandard(jsonPath(path)))
+}
+
+// JsonContains EXISTS (SELECT 1 FROM json_each(col, '$."a"') WHERE json_each.value = ?), value 为标量
+func (d *SQLite3Dialect) JsonContains(column string, path []string, value any) (string, []any, error) {
+	return fmt.Sprintf("EXISTS (SELECT 1 FROM json_each(%s, %s) WHERE json_each.value = ?)", column, literalQuoteStandard(jsonPath(path))), []any{value}, nil
+}
+
+// JsonLength json_array_length(col, '$."a"')
+func (d *SQLite3Dialect) JsonLength(column string, path []string) string {
+	return fmt.Sprintf("json_array_length(%s, %s)", column, literalQuoteStandard(jsonPath(path)))
+}
+
+// JsonSet json_set(col, '$."a"', ?), 对象和数组以 json(?) 写入
+func (d *SQLite3Dialect) JsonSet(expr string, path []string, value any) (string, []any, error) {
+	if jsonComposite(value) {
+		j, err := jsonMarshal(value)
+		if err != nil {
+			return "", nil, err
+		}
+		return fmt.Sprintf("json_set(%s, %s, json(?))", expr, literalQuoteStandard(jsonPath(path))), []any{j}, nil
+	}
+	return fmt.Sprintf("json_set(%s, %s, ?)", expr, literalQuoteStandard(jsonPath(path))), []any{value}, nil
+}
+
+// FullText fts5 虚拟表的 MATCH, column 为表名时检索所有列, 如 "docs" MATCH ?, 多列以 OR 连接
+func (d *SQLite3Dialect) FullText(columns []string, query, mode, language string) (string, []any) {
+	return fullTextEach(columns, query, "%s MATCH ?")
+}
+
+// FullTextScore fts5 的 rank 越小越相关, 取反为相关度; rank 为同一语句中 MATCH 条件的相关度,
+// 需要与 WhereFullText 一起使用, columns 和 query 不参与计算
+func (d *SQLite3Dialect) FullTextScore(columns []string, query, mode, language string) (string, []any) {
+	return "-rank", nil
+}
+
 func (d *SQLite3Dialect) Upsert() string {
 	return "INSERT OR REPLACE"
 }
 
-func (d *SQLite3Dialect) LockInShareMode() string { return "" }
-func (d *SQLite3Dialect) LockForUpdate() string   { return "" }
+// InsertConflict INSERT OR IGNORE, ON CONFLICT ("id") DO UPDATE SET "a"=excluded."a";
+// INSERT ... SELECT 带 ON CONFLICT 时子查询需要有 WHERE 条件(可用 WhereRaw("true")), 否则 ON 会被解析为 JOIN 的条件
+func (d *SQLite3Dialect) InsertConflict(ignore bool, keys, updates []string) (string, string, error) {
+	if ignore {
+		return "INSERT OR IGNORE", "", nil
+	}
+	suffix, err := onConflict(ignore, keys, updates)
+	return "INSERT", suffix, err
+}
+
+// LockSuffix sqlite3 没有行锁, 写事务锁定整个数据库, update 不需要锁定子句; 不支持 share, OF, NOWAIT, SKIP LOCKED
+func (d *SQLite3Dialect) LockSuffix(mode, wait string, of []string) (string, error) {
+	switch {
+	case mode == "share":
+		return "", errLock("sqlite3", "share lock")
+	case len(of) > 0:
+		return "", errLock("sqlite3", "lock of tables")
+	case wait != "":
+		return "", errLock("sqlite3", wait)
+	}
+	return "", nil
+}
+func (d *SQLite3Dialect) LockHint(mode, wait string) (string, error) { return "", nil }
+
+func (d *SQLite3Dialect) SavePoint(name string) string {
+	return "SAVEPOINT " + d.QuoteIdentifier(name)
+}
+
+// MutationJoin UPDATE a SET ... FROM b WHERE (3.33+), 不支持 DELETE ... JOIN
+func (d *SQLite3Dialect) MutationJoin(statement string) string {
+	if statement == "update" {
+		return "from"
+	}
+	return ""
+}
+
+// MutationLimit 需要编译选项 SQLITE_ENABLE_UPDATE_DELETE_LIMIT, 默认不支持
+func (d *SQLite3Dialect) MutationLimit(limit int) (top, suffix string, ordered bool) {
+	return "", "", false
+}
+
+func (d *SQLite3Dialect) MaxBinds() int { return 32766 }
+func (d *SQLite3Dialect) RollbackTo(name string) string {
+	return "ROLLBACK TO SAVEPOINT " + d.QuoteIdentifier(name)
+}
+func (d *SQLite3Dialect) ReleaseSavePoint(name string) string {
+	return "RELEASE SAVEPOINT " + d.QuoteIdentifier(name)
+}
+
+var sqlite3Literal = literalFormat{
+	quote:  literalQuoteStandard,
+	bytes:  literalBytesX,
+	time:   func(t time.Time) string { return "'" + t.Format("2006-01-02 15:04:05.999999999-07:00") + "'" },
+	true_:  "1",
+	false_: "0",
+}
+
+func (d *SQLite3Dialect) Literal(v any) string { return literal(v, sqlite3Literal) }
+
+var sqlite3ConstraintRegexp = regexp.MustCompile(`constraint failed: (\S+)`)
+
+// sqlite3ClassifyError sqlite 只能通过错误信息识别, 约束名为 表名.字段名
+func sqlite3ClassifyError(err error) (kind error, constraint string) {
+	switch {
+	case errorContains(err, "UNIQUE constraint failed", "PRIMARY KEY constraint failed"):
+		kind = ErrDuplicateKey
+	case errorContains(err, "FOREIGN KEY constraint failed"):
+		kind = ErrForeignKey
+	case errorContains(err, "NOT NULL constraint failed"):
+		kind = ErrNotNull
+	default:
+		return
+	}
+	return kind, errorSubmatch(err, sqlite3ConstraintRegexp)
+}
+
+// IsRetryable 5: SQLITE_BUSY, 6: SQLITE_LOCKED
+func (d *SQLite3Dialect) IsRetryable(err error) bool {
+	if number, ok := ErrorNumber(err); ok {
+		return number == 5 || number == 6
+	}
+	return errorContains(err, "database is locked")
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/gohouse/gorose/v3/driver/dialect"
 	"github.com/gohouse/gorose/v3/parser"
 	"regexp"
+	"slices"
 	"sort"
 
 	"reflect"
@@ -27,6 +28,8 @@ type IDriver interface {
 	ToSqlDelete(c *builder.Context, obj any, mustColumn ...string) (sqlSegment string, binds []any, err error)
 }
 
+var errTenantValueRequired = errors.New("tenant value is required in tenant mode")
+
 type Driver struct {
 	//driver string
 	Dialect dialect.IDialect
@@ -36,13 +39,23 @@ func NewDriver(driver string) *Driver {
 	return &Driver{Dialect: dialect.GetDialect(driver).New()}
 }
 
+// ToSql 生成查询语句, 占位符按方言统一编号
 func (d Driver) ToSql(c *builder.Context) (sql4prepare string, binds []any, err error) {
+	sql4prepare, binds, err = d.toSqlUnion(c)
+	return d.Renumber(sql4prepare), binds, err
+}
+
+// toSqlUnion 生成包含 union 的查询语句, 占位符为 ?
+func (d Driver) toSqlUnion(c *builder.Context) (sql4prepare string, binds []any, err error) {
 	sql4prepare, binds, err = d.toSql(c)
+	if err != nil {
+		return
+	}
 	if len(c.UnionClause.Unions) > 0 {
 		for _, u := range c.UnionClause.Unions {
-			sql4prepare2, binds2, err2 := u.ToSql()
+			sql4prepare2, binds2, err2 := d.subQuery(u)
 			if err2 != nil {
-				return
+				return sql4prepare, binds, err2
 			}
 			if sql4prepare2 == "" {
 				continue
@@ -58,6 +71,12 @@ func (d Driver) ToSql(c *builder.Context) (sql4prepare string, binds []any, err
 	return
 }
 func (d Driver) toSql(c *builder.Context) (sql4prepare string, binds []any, err error) {
+	if c.SelectClause.Err != nil {
+		return sql4prepare, binds, c.SelectClause.Err
+	}
+	if c.OrderByClause.Err != nil {
+		return sql4prepare, binds, c.OrderByClause.Err
+	}
 	selects, anies := d.ToSqlSelect(c)
 	table, binds2, err := d.ToSqlTable(c)
 	if err != nil {
@@ -71,23 +90,47 @@ func (d Driver) toSql(c *builder.Context) (sql4prepare string, binds []any, err
 	if err != nil {
 		return sql4prepare, binds4, err
 	}
-	orderBy := d.ToSqlOrderBy(c)
+	orderBy, binds7 := d.toSqlOrderBy(c)
 	limit, binds5 := d.ToSqlLimitOffset(c)
-	groupBys := d.ToSqlGroupBy(c)
+	groupBys, binds8 := d.toSqlGroupBy(c)
 	havings, binds6, err := d.ToSqlHaving(c)
+	if err != nil {
+		return sql4prepare, binds6, err
+	}
 
+	// 绑定参数与语句中占位符的顺序一致
 	binds = append(binds, anies...)
 	binds = append(binds, binds2...)
 	binds = append(binds, binds3...)
 	binds = append(binds, binds4...)
-	binds = append(binds, binds5...)
+	binds = append(binds, binds8...)
 	binds = append(binds, binds6...)
+	binds = append(binds, binds7...)
+	binds = append(binds, binds5...)
 
 	var locking string
-	if c.PessimisticLocking == builder.TypeLockInShareMode {
-		locking = d.Dialect.LockInShareMode()
-	} else if c.PessimisticLocking == builder.TypeLockForUpdate {
-		locking = d.Dialect.LockForUpdate()
+	if mode := lockMode(c.PessimisticLocking); mode != "" {
+		var of []string
+		for _, table := range c.LockOptions.Of {
+			of = append(of, d.Dialect.QuoteIdentifier(table))
+		}
+		if locking, err = d.Dialect.LockSuffix(mode, c.LockOptions.Wait, of); err != nil {
+			return
+		}
+		var hint string
+		if hint, err = d.Dialect.LockHint(mode, c.LockOptions.Wait); err != nil {
+			return
+		}
+		if hint != "" {
+			if len(of) > 0 { // 表提示只作用于紧跟的表, 无法指定 OF
+				err = errors.New("lock of tables is not supported by the dialect")
+				return
+			}
+			table = fmt.Sprintf("%s %s", table, hint)
+		}
+	} else if c.LockOptions.Wait != "" || len(c.LockOptions.Of) > 0 {
+		err = errors.New("SkipLocked, NoWait and LockOf require a lock, such as LockForUpdate")
+		return
 	}
 
 	//sql4prepare = NamedSprintf("SELECT :selects FROM :table :join :wheres :groupBys :havings :orderBy :pagination :PessimisticLocking", selects, table, joins, wheres, groupBys, havings, orderBy, limit, c.PessimisticLocking)
@@ -96,11 +139,24 @@ func (d Driver) toSql(c *builder.Context) (sql4prepare string, binds []any, err
 	return
 }
 
+// lockMode 行锁的方言模式
+func lockMode(lock builder.TypeLock) string {
+	switch lock {
+	case builder.TypeLockInShareMode:
+		return "share"
+	case builder.TypeLockForUpdate:
+		return "update"
+	case builder.TypeLockForNoKeyUpdate:
+		return "no key update"
+	}
+	return ""
+}
+
 func (d Driver) ToSqlSelect(c *builder.Context) (sql4prepare string, binds []any) {
 	var cols []string
 	for _, col := range c.SelectClause.Columns {
 		if col.IsRaw {
-			cols = append(cols, col.Name)
+			cols = append(cols, rawSql(col.Name, col.Binds))
 			binds = append(binds, col.Binds...)
 		} else {
 			if col.Alias == "" {
@@ -127,7 +183,7 @@ func (d Driver) ToSqlTable(c *builder.Context) (sql4prepare string, binds []any,
 
 func (d Driver) buildSqlTable(tab builder.TableClause, prefix string) (sql4prepare string, binds []any, err error) {
 	if v, ok := tab.Tables.(builder.IBuilder); ok {
-		sql4prepare, binds, err = v.ToSql()
+		sql4prepare, binds, err = d.subQuery(v)
 		if tab.Alias != "" {
 			sql4prepare = fmt.Sprintf("(%s) %s", sql4prepare, d.Dialect.QuoteIdentifier(tab.Alias))
 		}
@@ -154,6 +210,9 @@ func (d Driver) buildSqlTable(tab builder.TableClause, prefix string) (sql4prepa
 }
 
 func (d Driver) toSqlWhere(c *builder.Context, wc builder.WhereClause) (sql4prepare string, binds []any, err error) {
+	if wc.Err != nil {
+		return "", nil, wc.Err
+	}
 	if len(wc.Conditions) == 0 {
 		return
 	}
@@ -161,24 +220,68 @@ func (d Driver) toSqlWhere(c *builder.Context, wc builder.WhereClause) (sql4prep
 	for _, v := range wc.Conditions {
 		switch item := v.(type) {
 		case builder.TypeWhereRaw:
-			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s", item.LogicalOp, item.Column))
+			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s", item.LogicalOp, rawSql(item.Column, item.Bindings)))
 			binds = append(binds, item.Bindings...)
 		case builder.TypeWhereStandard:
-			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s %s", item.LogicalOp, d.Dialect.QuoteIdentifier(item.Column), item.Operator, d.Dialect.Placeholder()))
-			binds = append(binds, item.Value)
+			ph, phBinds := placeholder(item.Value)
+			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s %s", item.LogicalOp, d.quoteColumn(item.Column), literal(item.Operator), ph))
+			binds = append(binds, phBinds...)
 		case builder.TypeWhereIn:
 			values := ToSlice(item.Value)
 			var phs []string
-			for range values {
-				phs = append(phs, d.Dialect.Placeholder())
+			var phBinds []any
+			for _, value := range values {
+				ph, b := placeholder(value)
+				phs = append(phs, ph)
+				phBinds = append(phBinds, b...)
 			}
-			//sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s (%s)", item.LogicalOp, d.Dialect.QuoteIdentifier(item.Column), item.Operator, strings.Repeat("?,", len(values)-1)+"?"))
-			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s (%s)", item.LogicalOp, d.Dialect.QuoteIdentifier(item.Column), item.Operator, strings.Join(phs, ",")))
-			binds = append(binds, values...)
+			//sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s (%s)", item.LogicalOp, d.quoteColumn(item.Column), item.Operator, strings.Repeat("?,", len(values)-1)+"?"))
+			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s (%s)", item.LogicalOp, d.quoteColumn(item.Column), item.Operator, strings.Join(phs, ",")))
+			binds = append(binds, phBinds...)
 		case builder.TypeWhereBetween:
 			values := ToSlice(item.Value)
-			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s %s AND %s", item.LogicalOp, d.Dialect.QuoteIdentifier(item.Column), d.Dialect.Placeholder(), item.Operator, d.Dialect.Placeholder()))
-			binds = append(binds, values...)
+			if len(values) != 2 {
+				return sql4prepare, binds, errors.New("between values must be 2 items")
+			}
+			ph1, binds1 := placeholder(values[0])
+			ph2, binds2 := placeholder(values[1])
+			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s %s AND %s", item.LogicalOp, d.quoteColumn(item.Column), item.Operator, ph1, ph2))
+			binds = append(binds, binds1...)
+			binds = append(binds, binds2...)
+		case builder.TypeWhereColumn:
+			dotted := d.dotted()
+			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s %s", item.LogicalOp, dotted.quoteColumn(item.Column1), literal(item.Operator), dotted.quoteColumn(item.Column2)))
+		case builder.TypeWhereDate:
+			ph, phBinds := placeholder(item.Value)
+			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s %s", item.LogicalOp, d.Dialect.DatePart(item.Part, d.dotted().quoteColumn(item.Column)), literal(item.Operator), ph))
+			binds = append(binds, phBinds...)
+		case builder.TypeWhereJsonContains:
+			col, path := splitJsonColumn(item.Column)
+			contains, phBinds, err := d.Dialect.JsonContains(d.Dialect.QuoteIdentifier(col), path, item.Value)
+			if err != nil {
+				return sql4prepare, binds, err
+			}
+			if item.Not {
+				contains = "NOT " + contains
+			}
+			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s", item.LogicalOp, contains))
+			binds = append(binds, phBinds...)
+		case builder.TypeWhereJsonLength:
+			col, path := splitJsonColumn(item.Column)
+			ph, phBinds := placeholder(item.Value)
+			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s %s", item.LogicalOp, d.Dialect.JsonLength(d.Dialect.QuoteIdentifier(col), path), literal(item.Operator), ph))
+			binds = append(binds, phBinds...)
+		case builder.TypeWhereFullText:
+			var columns []string
+			for _, column := range item.Columns {
+				columns = append(columns, d.Dialect.QuoteIdentifier(column))
+			}
+			match, phBinds := d.Dialect.FullText(columns, item.Query, item.Options.Mode, item.Options.Language)
+			if match == "" {
+				return sql4prepare, binds, errors.New("full text search not supported by the dialect")
+			}
+			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s", item.LogicalOp, match))
+			binds = append(binds, phBinds...)
 		case builder.TypeWhereNested:
 			var tmp = builder.Context{}
 			item.WhereNested(&tmp.WhereClause)
@@ -189,20 +292,21 @@ func (d Driver) toSqlWhere(c *builder.Context, wc builder.WhereClause) (sql4prep
 			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s (%s)", item.LogicalOp, strings.TrimPrefix(prepare, "WHERE ")))
 			binds = append(binds, anies...)
 		case builder.TypeWhereSubQuery:
-			query, anies, err := item.SubQuery.ToSql()
+			query, anies, err := d.subQuery(item.SubQuery)
 			if err != nil {
 				return sql4prepare, binds, err
 			}
-			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s (%s)", item.LogicalOp, d.Dialect.QuoteIdentifier(item.Column), item.Operator, query))
+			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s (%s)", item.LogicalOp, d.quoteColumn(item.Column), literal(item.Operator), query))
 			binds = append(binds, anies...)
 		case builder.TypeWhereSubHandler:
 			var ctx = builder.NewContext(c.Prefix)
+			ctx.TenantClause = c.TenantClause
 			item.Sub(ctx)
-			query, anies, err := d.ToSql(ctx)
+			query, anies, err := d.toSqlUnion(ctx)
 			if err != nil {
 				return sql4prepare, binds, err
 			}
-			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s (%s)", item.LogicalOp, d.Dialect.QuoteIdentifier(item.Column), item.Operator, query))
+			sql4prepareArr = append(sql4prepareArr, fmt.Sprintf("%s %s %s (%s)", item.LogicalOp, d.quoteColumn(item.Column), literal(item.Operator), query))
 			binds = append(binds, anies...)
 		}
 	}
@@ -213,63 +317,156 @@ func (d Driver) toSqlWhere(c *builder.Context, wc builder.WhereClause) (sql4prep
 }
 func (d Driver) ToSqlWhere(c *builder.Context) (sql4prepare string, binds []any, err error) {
 	sql4prepare, binds, err = d.toSqlWhere(c, c.WhereClause)
-	if sql4prepare != "" {
-		if c.WhereClause.Not {
-			sql4prepare = fmt.Sprintf("WHERE NOT %s", sql4prepare)
+	if err != nil {
+		return
+	}
+	if sql4prepare != "" && c.WhereClause.Not {
+		sql4prepare = fmt.Sprintf("NOT %s", sql4prepare)
+	}
+	tenant, tenantBinds, err := d.toSqlTenant(c, c.TableClause)
+	if err != nil {
+		return
+	}
+	if tenant != "" {
+		if sql4prepare != "" {
+			sql4prepare = fmt.Sprintf("(%s) AND %s", sql4prepare, tenant)
+		} else {
+			sql4prepare = tenant
 		}
+		binds = append(binds, tenantBinds...)
+	}
+	if sql4prepare != "" {
 		sql4prepare = fmt.Sprintf("WHERE %s", sql4prepare)
 	}
 	return
 }
 
-func (d Driver) ToSqlJoin(c *builder.Context) (sql4prepare string, binds []any, err error) {
-	if c.JoinClause.Err != nil {
-		return sql4prepare, binds, c.JoinClause.Err
+// toSqlTenant 构建多租户隔离条件, 如: "users"."tenant_id" = ?
+// 未开启多租户模式, 或者表为子查询(子查询需自行指定租户)时, 返回空
+func (d Driver) toSqlTenant(c *builder.Context, tab builder.TableClause) (sql4prepare string, binds []any, err error) {
+	if c.TenantClause.Column == "" {
+		return
+	}
+	if c.TenantClause.Value == nil {
+		err = errTenantValueRequired
+		return
+	}
+	var table = tab.Alias
+	if table == "" {
+		table = TableName(tab, c.Prefix)
 	}
-	if len(c.JoinClause.JoinItems) == 0 {
+	if table == "" {
+		return
+	}
+	sql4prepare = fmt.Sprintf("%s.%s = ?", d.Dialect.QuoteIdentifier(table), d.Dialect.QuoteIdentifier(c.TenantClause.Column))
+	binds = append(binds, c.TenantClause.Value)
+	return
+}
+
+func (d Driver) ToSqlJoin(c *builder.Context) (sql4prepare string, binds []any, err error) {
+	items, err := d.toSqlJoinItems(c)
+	if err != nil {
 		return
 	}
+	for _, item := range items {
+		var sql4 = item.table
+		if item.joinType != "" {
+			sql4 = fmt.Sprintf("%s %s ON %s", item.joinType, item.table, item.on)
+		}
+		sql4prepare = fmt.Sprintf("%s %s", sql4prepare, sql4)
+		binds = append(binds, item.tableBinds...)
+		binds = append(binds, item.onBinds...)
+	}
+	return
+}
+
+// joinItem 拆分为表和关联条件的 join, 用于 UPDATE/DELETE 的 FROM/USING 写法; 子查询 join 的 joinType 为空, table 为整个子句
+type joinItem struct {
+	joinType   string
+	table      string
+	on         string
+	tableBinds []any
+	onBinds    []any
+}
+
+func (d Driver) toSqlJoinItems(c *builder.Context) (items []joinItem, err error) {
+	if c.JoinClause.Err != nil {
+		return items, c.JoinClause.Err
+	}
 	for _, v := range c.JoinClause.JoinItems {
-		var prepare string
-		var sql4 string
-		var bind []any
-		switch item := v.(type) {
+		var item joinItem
+		switch v := v.(type) {
 		case builder.TypeJoinStandard:
-			prepare, bind, err = d.buildSqlTable(item.TableClause, c.Prefix)
+			item.table, item.tableBinds, err = d.buildSqlTable(v.TableClause, c.Prefix)
 			if err != nil {
 				return
 			}
-			sql4 = fmt.Sprintf("%s %s ON %s %s %s", item.Type, prepare, d.Dialect.QuoteIdentifier(item.Column1), item.Operator, d.Dialect.QuoteIdentifier(item.Column2))
+			var column2 = v.Column2
+			if v.IsRaw {
+				column2 = rawSql(column2, v.Binds)
+				item.onBinds = append(item.onBinds, v.Binds...)
+			} else {
+				column2 = d.Dialect.QuoteIdentifier(column2)
+			}
+			item.joinType = v.Type
+			item.on = fmt.Sprintf("%s %s %s", d.Dialect.QuoteIdentifier(v.Column1), literal(v.Operator), column2)
+			tenant, tenantBinds, err := d.toSqlTenant(c, v.TableClause)
+			if err != nil {
+				return items, err
+			}
+			if tenant != "" {
+				item.on = fmt.Sprintf("%s AND %s", item.on, tenant)
+				item.onBinds = append(item.onBinds, tenantBinds...)
+			}
 		case builder.TypeJoinSub:
-			sql4, bind, err = item.ToSql()
+			item.table, item.tableBinds, err = d.subQuery(v.IBuilder)
 			if err != nil {
 				return
 			}
 		case builder.TypeJoinOn:
 			var tjo builder.TypeJoinOnCondition
-			item.OnClause(&tjo)
+			v.OnClause(&tjo)
 			if len(tjo.Conditions) == 0 {
 				return
 			}
+			item.table, item.tableBinds, err = d.buildSqlTable(v.TableClause, c.Prefix)
+			if err != nil {
+				return
+			}
 			var sqlArr []string
 			for _, cond := range tjo.Conditions {
-				sqlArr = append(sqlArr, fmt.Sprintf("%s %s %s %s", cond.Relation, d.Dialect.QuoteIdentifier(cond.Column1), cond.Operator, d.Dialect.QuoteIdentifier(cond.Column2)))
+				sqlArr = append(sqlArr, fmt.Sprintf("%s %s %s %s", cond.Relation, d.Dialect.QuoteIdentifier(cond.Column1), literal(cond.Operator), d.Dialect.QuoteIdentifier(cond.Column2)))
 			}
 
-			sql4 = TrimPrefixAndOr(strings.Join(sqlArr, " "))
+			item.joinType = v.Type
+			item.on = TrimPrefixAndOr(strings.Join(sqlArr, " "))
+			tenant, tenantBinds, err := d.toSqlTenant(c, v.TableClause)
+			if err != nil {
+				return items, err
+			}
+			if tenant != "" {
+				item.on = fmt.Sprintf("(%s) AND %s", item.on, tenant)
+				item.onBinds = append(item.onBinds, tenantBinds...)
+			}
 		}
-		sql4prepare = fmt.Sprintf("%s %s", sql4prepare, sql4)
-		binds = append(binds, bind...)
+		items = append(items, item)
 	}
 	return
 }
 
 func (d Driver) ToSqlGroupBy(c *builder.Context) (sql4prepare string) {
+	sql4prepare, _ = d.toSqlGroupBy(c)
+	return
+}
+
+// toSqlGroupBy 同 ToSqlGroupBy, 同时返回表达式的绑定参数
+func (d Driver) toSqlGroupBy(c *builder.Context) (sql4prepare string, binds []any) {
 	if len(c.GroupClause.Groups) > 0 {
 		var tmp []string
 		for _, col := range c.GroupClause.Groups {
 			if col.IsRaw {
-				tmp = append(tmp, col.Column)
+				tmp = append(tmp, rawSql(col.Column, col.Binds))
+				binds = append(binds, col.Binds...)
 			} else {
 				tmp = append(tmp, d.Dialect.QuoteIdentifier(col.Column))
 			}
@@ -286,13 +483,24 @@ func (d Driver) ToSqlHaving(c *builder.Context) (sql4prepare string, binds []any
 	return
 }
 func (d Driver) ToSqlOrderBy(c *builder.Context) (sql4prepare string) {
+	sql4prepare, _ = d.toSqlOrderBy(c)
+	return
+}
+
+// toSqlOrderBy 同 ToSqlOrderBy, 同时返回表达式的绑定参数
+func (d Driver) toSqlOrderBy(c *builder.Context) (sql4prepare string, binds []any) {
 	if len(c.OrderByClause.Columns) == 0 {
 		return
 	}
 	var orderBys []string
 	for _, v := range c.OrderByClause.Columns {
 		if v.IsRaw {
-			orderBys = append(orderBys, v.Column)
+			binds = append(binds, v.Binds...)
+			if v.Direction == "" {
+				orderBys = append(orderBys, rawSql(v.Column, v.Binds))
+			} else {
+				orderBys = append(orderBys, fmt.Sprintf("%s %s", rawSql(v.Column, v.Binds), v.Direction))
+			}
 		} else {
 			if v.Direction == "" {
 				orderBys = append(orderBys, d.Dialect.QuoteIdentifier(v.Column))
@@ -327,6 +535,11 @@ func (d Driver) ToSqlLimitOffset(c *builder.Context) (sqlSegment string, binds [
 
 // ToSqlInsert insert
 func (d Driver) ToSqlInsert(c *builder.Context, obj any, args ...builder.TypeToSqlInsertCase) (sqlSegment string, binds []any, err error) {
+	sqlSegment, binds, err = d.toSqlInsertObj(c, obj, args...)
+	return d.Renumber(sqlSegment), binds, err
+}
+
+func (d Driver) toSqlInsertObj(c *builder.Context, obj any, args ...builder.TypeToSqlInsertCase) (sqlSegment string, binds []any, err error) {
 	var arg builder.TypeToSqlInsertCase
 	if len(args) > 0 {
 		arg = args[0]
@@ -361,6 +574,12 @@ func (d Driver) ToSqlInsert(c *builder.Context, obj any, args ...builder.TypeToS
 }
 
 func (d Driver) ToSqlDelete(c *builder.Context, obj any, mustColumn ...string) (sqlSegment string, binds []any, err error) {
+	d = d.mutation(c)
+	sqlSegment, binds, err = d.toSqlDeleteObj(c, obj, mustColumn...)
+	return d.Renumber(sqlSegment), binds, err
+}
+
+func (d Driver) toSqlDeleteObj(c *builder.Context, obj any, mustColumn ...string) (sqlSegment string, binds []any, err error) {
 	var ctx = *c
 	rfv := reflect.Indirect(reflect.ValueOf(obj))
 	switch rfv.Kind() {
@@ -375,6 +594,12 @@ func (d Driver) ToSqlDelete(c *builder.Context, obj any, mustColumn ...string) (
 	case reflect.Int64, reflect.Int32, reflect.String:
 		ctx.WhereClause.Where("id", obj)
 		return d.toSqlDelete(&ctx)
+	case reflect.Invalid:
+		// obj 为 nil 时按 where 条件删除, 如 Join(...).Where(...).Delete(nil), 必须有条件
+		if len(ctx.WhereClause.Conditions) == 0 {
+			return sqlSegment, binds, errors.New("delete without obj requires where conditions")
+		}
+		return d.toSqlDelete(&ctx)
 	default:
 		err = errors.New("obj must be struct or id value")
 	}
@@ -382,6 +607,12 @@ func (d Driver) ToSqlDelete(c *builder.Context, obj any, mustColumn ...string) (
 }
 
 func (d Driver) ToSqlUpdate(c *builder.Context, arg any) (sqlSegment string, binds []any, err error) {
+	d = d.mutation(c)
+	sqlSegment, binds, err = d.toSqlUpdateCase(c, arg)
+	return d.Renumber(sqlSegment), binds, err
+}
+
+func (d Driver) toSqlUpdateCase(c *builder.Context, arg any) (sqlSegment string, binds []any, err error) {
 	switch v := arg.(type) {
 	case builder.TypeToSqlUpdateCase:
 		return d.toSqlUpdate(c, v.BindOrData, v.MustColumn...)
@@ -405,6 +636,12 @@ func (d Driver) toSqlUpdate(c *builder.Context, obj any, mustColumn ...string) (
 		if pk != "" {
 			ctx.WhereClause.Where(pk, pkValue)
 		}
+		// 乐观锁: WHERE version = ? , SET version = version + 1
+		if versionTag, versionValue := parser.StructToVersion(obj); versionTag != "" {
+			delete(dataMap, versionTag)
+			ctx.WhereClause.Where(versionTag, versionValue)
+			return d.toSqlUpdateReal(&ctx, dataMap, versionTag)
+		}
 		return d.toSqlUpdateReal(&ctx, dataMap)
 	case reflect.Map:
 		return d.toSqlUpdateReal(c, obj)
@@ -415,26 +652,29 @@ func (d Driver) toSqlUpdate(c *builder.Context, obj any, mustColumn ...string) (
 }
 
 func (d Driver) toSqlIncDec(c *builder.Context, symbol string, data map[string]any) (sql4prepare string, values []any, err error) {
-	prepare, anies, err := d.ToSqlTable(c)
-	if err != nil {
-		return sql4prepare, values, err
-	}
-	values = append(values, anies...)
-
 	var tmp []string
 	for k, v := range data {
-		tmp = append(tmp, fmt.Sprintf("%s=%s%s%s", d.Dialect.QuoteIdentifier(k), d.Dialect.QuoteIdentifier(k), symbol, d.Dialect.Placeholder()))
-		values = append(values, v)
+		ph, binds := placeholder(v)
+		tmp = append(tmp, fmt.Sprintf("%s=%s%s%s", d.Dialect.QuoteIdentifier(k), d.Dialect.QuoteIdentifier(k), symbol, ph))
+		values = append(values, binds...)
 	}
+	return d.toSqlUpdateSets(c, strings.Join(tmp, ","), values)
+}
 
-	where, val, err := d.ToSqlWhere(c)
-	if err != nil {
-		return sql4prepare, values, err
+// TableName 获取带前缀的表名, 不含引号和别名, 表为子查询时返回空
+func TableName(tab builder.TableClause, prefix string) string {
+	rfv := reflect.Indirect(reflect.ValueOf(tab.Tables))
+	switch rfv.Kind() {
+	case reflect.String:
+		return fmt.Sprintf("%s%s", prefix, rfv.String())
+	case reflect.Struct:
+		return fmt.Sprintf("%s%s", prefix, parser.StructsToTableName(rfv.Type()))
+	case reflect.Slice:
+		if rfv.Type().Elem().Kind() == reflect.Struct {
+			return fmt.Sprintf("%s%s", prefix, parser.StructsToTableName(rfv.Type().Elem()))
+		}
 	}
-	values = append(values, val...)
-
-	sql4prepare = fmt.Sprintf("UPDATE %s SET %s %s", prepare, strings.Join(tmp, ","), where)
-	return
+	return ""
 }
 
 func (d Driver) buildTableName(rft reflect.Type, prefix string) (tab string) {
@@ -443,6 +683,14 @@ func (d Driver) buildTableName(rft reflect.Type, prefix string) (tab string) {
 
 // func (b Driver) toSqlInsert(c *gorose.Context, data any, ignoreCase string, onDuplicateKeys []string) (sql4prepare string, values []any, err error) {
 func (d Driver) toSqlInsert(c *builder.Context, data any, insertCase builder.TypeToSqlInsertCase) (sql4prepare string, values []any, err error) {
+	if insertCase.Using != nil {
+		return d.toSqlInsertUsing(c, insertCase)
+	}
+	if c.TenantClause.Column != "" {
+		if data, err = d.fillTenant(c, data); err != nil {
+			return
+		}
+	}
 	rfv := reflect.Indirect(reflect.ValueOf(data))
 	var fields []string
 	var valuesPlaceholderArr []string
@@ -455,8 +703,9 @@ func (d Driver) toSqlInsert(c *builder.Context, data any, insertCase builder.Typ
 		var valuesPlaceholderTmp []string
 		for _, key := range keys {
 			fields = append(fields, d.Dialect.QuoteIdentifier(key.String()))
-			valuesPlaceholderTmp = append(valuesPlaceholderTmp, d.Dialect.Placeholder())
-			values = append(values, rfv.MapIndex(key).Interface())
+			ph, binds := placeholder(rfv.MapIndex(key).Interface())
+			valuesPlaceholderTmp = append(valuesPlaceholderTmp, ph)
+			values = append(values, binds...)
 		}
 		valuesPlaceholderArr = append(valuesPlaceholderArr, fmt.Sprintf("(%s)", strings.Join(valuesPlaceholderTmp, ",")))
 	case reflect.Slice:
@@ -476,8 +725,9 @@ func (d Driver) toSqlInsert(c *builder.Context, data any, insertCase builder.Typ
 			for i := 0; i < rfv.Len(); i++ {
 				var valuesPlaceholderTmp []string
 				for _, key := range keys {
-					valuesPlaceholderTmp = append(valuesPlaceholderTmp, d.Dialect.Placeholder())
-					values = append(values, rfv.Index(i).MapIndex(key).Interface())
+					ph, binds := placeholder(rfv.Index(i).MapIndex(key).Interface())
+					valuesPlaceholderTmp = append(valuesPlaceholderTmp, ph)
+					values = append(values, binds...)
 				}
 				valuesPlaceholderArr = append(valuesPlaceholderArr, fmt.Sprintf("(%s)", strings.Join(valuesPlaceholderTmp, ",")))
 			}
@@ -492,21 +742,51 @@ func (d Driver) toSqlInsert(c *builder.Context, data any, insertCase builder.Typ
 	if err != nil {
 		return
 	}
+	sql4prepare, err = d.toSqlInsertInto(c, fields, "VALUES "+strings.Join(valuesPlaceholderArr, ","), insertCase)
+	return
+}
 
-	var onDuplicateKey string
-	if len(insertCase.UpdateFields) > 0 {
-		var tmp []string
-		for _, v := range insertCase.UpdateFields {
-			tmp = append(tmp, fmt.Sprintf("%s=VALUES(%s)", d.Dialect.QuoteIdentifier(v), d.Dialect.QuoteIdentifier(v)))
-		}
-		onDuplicateKey = fmt.Sprintf("%s %s", d.Dialect.Upsert(), strings.Join(tmp, ", "))
+// toSqlInsertUsing INSERT INTO t (cols) SELECT ..., 子查询的绑定参数作为语句的绑定参数
+//
+// 多租户模式下 columns 需包含租户字段, 由子查询提供租户字段的值
+func (d Driver) toSqlInsertUsing(c *builder.Context, insertCase builder.TypeToSqlInsertCase) (sql4prepare string, values []any, err error) {
+	if len(insertCase.UsingColumns) == 0 {
+		return sql4prepare, values, errors.New("insert using columns required")
+	}
+	if c.TenantClause.Column != "" && !slices.Contains(insertCase.UsingColumns, c.TenantClause.Column) {
+		return sql4prepare, values, fmt.Errorf("insert using columns must contain the tenant column %s", c.TenantClause.Column)
+	}
+	var fields []string
+	for _, column := range insertCase.UsingColumns {
+		fields = append(fields, d.Dialect.QuoteIdentifier(column))
 	}
+	query, values, err := d.subQuery(insertCase.Using)
+	if err != nil {
+		return
+	}
+	sql4prepare, err = d.toSqlInsertInto(c, fields, query, insertCase)
+	return
+}
 
+// toSqlInsertInto 组合插入语句, source 为 VALUES (...) 或者 SELECT 子查询
+func (d Driver) toSqlInsertInto(c *builder.Context, fields []string, source string, insertCase builder.TypeToSqlInsertCase) (sql4prepare string, err error) {
+	var onDuplicateKey string
 	var insert = "INSERT"
 	if insertCase.IsReplace {
 		insert = "REPLACE"
-	} else if insertCase.IsIgnoreCase {
-		insert = "INSERT IGNORE"
+	} else if insertCase.IsIgnoreCase || len(insertCase.UpdateFields) > 0 {
+		var keys, updates []string
+		for _, v := range insertCase.OnDuplicateKeys {
+			keys = append(keys, d.Dialect.QuoteIdentifier(v))
+		}
+		for _, v := range insertCase.UpdateFields {
+			updates = append(updates, d.Dialect.QuoteIdentifier(v))
+		}
+		// 冲突处理按方言生成, 如 INSERT IGNORE, ON DUPLICATE KEY UPDATE, ON CONFLICT (...) DO UPDATE
+		insert, onDuplicateKey, err = d.Dialect.InsertConflict(insertCase.IsIgnoreCase, keys, updates)
+		if err != nil {
+			return
+		}
 	}
 
 	var tables string
@@ -514,12 +794,46 @@ func (d Driver) toSqlInsert(c *builder.Context, data any, insertCase builder.Typ
 	if err != nil {
 		return
 	}
-	//sql4prepare = NamedSprintf(":insert INTO :tables (:fields) VALUES :placeholder :onDuplicateKey", insert, tables, strings.Join(fields, ","), strings.Join(valuesPlaceholderArr, ","), onDuplicateKey)
-	sql4prepare = fmt.Sprintf("%s INTO %s (%s) VALUES %s %s", insert, tables, strings.Join(fields, ","), strings.Join(valuesPlaceholderArr, ","), onDuplicateKey)
+	//sql4prepare = NamedSprintf(":insert INTO :tables (:fields) :source :onDuplicateKey", insert, tables, strings.Join(fields, ","), source, onDuplicateKey)
+	sql4prepare = fmt.Sprintf("%s INTO %s (%s) %s %s", insert, tables, strings.Join(fields, ","), source, onDuplicateKey)
 	return
 }
 
-func (d Driver) toSqlUpdateReal(c *builder.Context, data any) (sql4prepare string, values []any, err error) {
+// fillTenant 多租户模式下, 为插入数据填充租户字段, 不修改原始数据
+func (d Driver) fillTenant(c *builder.Context, data any) (res any, err error) {
+	if c.TenantClause.Value == nil {
+		err = errTenantValueRequired
+		return
+	}
+	var fill = func(rfv reflect.Value) map[string]any {
+		var tmp = make(map[string]any, rfv.Len()+1)
+		iter := rfv.MapRange()
+		for iter.Next() {
+			tmp[iter.Key().String()] = iter.Value().Interface()
+		}
+		tmp[c.TenantClause.Column] = c.TenantClause.Value
+		return tmp
+	}
+	rfv := reflect.Indirect(reflect.ValueOf(data))
+	switch rfv.Kind() {
+	case reflect.Map:
+		return fill(rfv), nil
+	case reflect.Slice:
+		if rfv.Type().Elem().Kind() == reflect.Map {
+			var datas []map[string]any
+			for i := 0; i < rfv.Len(); i++ {
+				datas = append(datas, fill(rfv.Index(i)))
+			}
+			return datas, nil
+		}
+	}
+	return data, nil
+}
+
+// toSqlUpdateReal
+//
+//	incColumns: 需要自增1的字段, 如乐观锁版本字段 => version = version + 1
+func (d Driver) toSqlUpdateReal(c *builder.Context, data any, incColumns ...string) (sql4prepare string, values []any, err error) {
 	rfv := reflect.Indirect(reflect.ValueOf(data))
 	var updates []string
 	switch rfv.Kind() {
@@ -528,43 +842,44 @@ func (d Driver) toSqlUpdateReal(c *builder.Context, data any) (sql4prepare strin
 		sort.Slice(keys, func(i, j int) bool {
 			return keys[i].String() < keys[j].String()
 		})
+		// 同一 json 列的多个路径合并为一个嵌套的赋值, 如 meta = JSON_SET(JSON_SET(meta, a, ?), b, ?)
+		var jsonCol, jsonExpr string
 		for _, key := range keys {
-			updates = append(updates, fmt.Sprintf("%s = %s", d.Dialect.QuoteIdentifier(key.String()), d.Dialect.Placeholder()))
-			values = append(values, rfv.MapIndex(key).Interface())
+			col, path := splitJsonColumn(key.String())
+			if len(path) > 0 {
+				var expr = d.Dialect.QuoteIdentifier(col)
+				if col == jsonCol {
+					expr = jsonExpr
+				}
+				var binds []any
+				jsonExpr, binds, err = d.Dialect.JsonSet(expr, path, rfv.MapIndex(key).Interface())
+				if err != nil {
+					return
+				}
+				if col == jsonCol {
+					updates[len(updates)-1] = fmt.Sprintf("%s = %s", d.Dialect.QuoteIdentifier(col), jsonExpr)
+				} else {
+					jsonCol = col
+					updates = append(updates, fmt.Sprintf("%s = %s", d.Dialect.QuoteIdentifier(col), jsonExpr))
+				}
+				values = append(values, binds...)
+				continue
+			}
+			jsonCol = ""
+			ph, binds := placeholder(rfv.MapIndex(key).Interface())
+			updates = append(updates, fmt.Sprintf("%s = %s", d.Dialect.QuoteIdentifier(key.String()), ph))
+			values = append(values, binds...)
 		}
 	default:
 		err = errors.New("only map data supported")
 		return
 	}
-	var tables string
-	tables, _, err = d.ToSqlTable(c)
-	if err != nil {
-		return
+	for _, col := range incColumns {
+		updates = append(updates, fmt.Sprintf("%s = %s + 1", d.Dialect.QuoteIdentifier(col), d.Dialect.QuoteIdentifier(col)))
 	}
-	wheres, binds, err := d.ToSqlWhere(c)
-	if err != nil {
-		return sql4prepare, values, err
-	}
-	values = append(values, binds...)
-
-	//sql4prepare = NamedSprintf("UPDATE :tables SET :updates :wheres", tables, strings.Join(updates, ", "), wheres)
-	sql4prepare = fmt.Sprintf("UPDATE %s SET %s %s", tables, strings.Join(updates, ", "), wheres)
-
-	return
+	return d.toSqlUpdateSets(c, strings.Join(updates, ", "), values)
 }
 
 func (d Driver) toSqlDelete(c *builder.Context) (sql4prepare string, values []any, err error) {
-	var tables string
-	tables, _, err = d.ToSqlTable(c)
-	if err != nil {
-		return
-	}
-	wheres, binds, err := d.ToSqlWhere(c)
-	if err != nil {
-		return sql4prepare, values, err
-	}
-	values = append(values, binds...)
-	//sql4prepare = NamedSprintf("DELETE FROM :tables :wheres", tables, wheres)
-	sql4prepare = fmt.Sprintf("DELETE FROM %s %s", tables, wheres)
-	return
+	return d.toSqlMutationDelete(c)
 }
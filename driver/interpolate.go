@@ -0,0 +1,84 @@
+package driver
+
+import (
+	"github.com/gohouse/gorose/v3/driver/dialect"
+	"strconv"
+	"strings"
+)
+
+// Interpolate 将绑定值按方言的字面量写法替换到占位符(?, $n, @pn)中, 引号内的内容不做替换
+//
+// 仅用于调试输出, 如复制到数据库客户端中执行, 不能用于拼接执行的 sql
+func Interpolate(d dialect.IDialect, query string, bindings []any) string {
+	if len(bindings) == 0 {
+		return query
+	}
+	if d == nil {
+		d = &dialect.MySQLDialect{}
+	}
+	var b strings.Builder
+	b.Grow(len(query) + len(bindings)*8)
+	var next int                                // ? 对应的下一个绑定值
+	var numbered = d.New().Placeholder() != "?" // 编号占位符的方言中 ? 为字面量, 如 postgresql jsonb 的 ? 操作符
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			end := quoteEnd(query, i)
+			b.WriteString(query[i:end])
+			i = end - 1
+		case c == '?' && !numbered:
+			if next < len(bindings) {
+				b.WriteString(d.Literal(bindings[next]))
+				next++
+			} else {
+				b.WriteByte(c)
+			}
+		case (c == '$' || c == '@') && (i == 0 || !isIdentByte(query[i-1])):
+			start := i + 1
+			if c == '@' && start < len(query) && query[start] == 'p' {
+				start++
+			}
+			end := start
+			for end < len(query) && query[end] >= '0' && query[end] <= '9' {
+				end++
+			}
+			if n, err := strconv.Atoi(query[start:end]); err == nil && n >= 1 && n <= len(bindings) {
+				b.WriteString(d.Literal(bindings[n-1]))
+				i = end - 1
+			} else {
+				b.WriteByte(c)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// quoteEnd 从 start 处的引号开始, 返回对应的结束引号之后的位置; 字符串中连续的两个单引号和反斜杠转义不作为结束
+func quoteEnd(query string, start int) int {
+	var end = query[start]
+	if end == '[' {
+		end = ']'
+	}
+	for i := start + 1; i < len(query); i++ {
+		switch query[i] {
+		case '\\':
+			if end == '\'' {
+				i++
+			}
+		case end:
+			if end == '\'' && i+1 < len(query) && query[i+1] == '\'' {
+				i++
+				continue
+			}
+			return i + 1
+		}
+	}
+	return len(query)
+}
+
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
@@ -0,0 +1,22 @@
+package driver
+
+import (
+	"strings"
+)
+
+// json 路径的列以 -> 分隔, 如 meta->address->city, 其中数字为数组下标, 如 meta->tags->0
+
+// splitJsonColumn 拆分 json 列和路径, 非 json 列 path 为空
+func splitJsonColumn(column string) (col string, path []string) {
+	parts := strings.Split(column, "->")
+	return strings.TrimSpace(parts[0]), parts[1:]
+}
+
+// quoteColumn 列名加引号, json 路径列转换为方言的取值表达式
+func (d Driver) quoteColumn(column string) string {
+	col, path := splitJsonColumn(column)
+	if len(path) == 0 {
+		return d.Dialect.QuoteIdentifier(column)
+	}
+	return d.Dialect.JsonExtract(d.Dialect.QuoteIdentifier(col), path)
+}
@@ -0,0 +1,201 @@
+package driver
+
+import (
+	"errors"
+	"fmt"
+	"github.com/gohouse/gorose/v3/builder"
+	"strings"
+)
+
+// UPDATE/DELETE 的 JOIN, ORDER BY, LIMIT 按方言生成, 见 dialect.IDialect.MutationJoin, MutationLimit;
+// 方言无法支持的子句返回错误, 避免静默忽略后更新或删除超出预期的行
+
+// mutation 带 JOIN 时列名可能为 table.column 形式, 按 . 分段加引号
+func (d Driver) mutation(c *builder.Context) Driver {
+	if len(c.JoinClause.JoinItems) == 0 {
+		return d
+	}
+	return d.dotted()
+}
+
+// toSqlUpdateSets 组合 UPDATE 语句, sets 为 SET 子句的赋值, setBinds 为其绑定参数
+func (d Driver) toSqlUpdateSets(c *builder.Context, sets string, setBinds []any) (sql4prepare string, values []any, err error) {
+	tables, _, err := d.ToSqlTable(c)
+	if err != nil {
+		return
+	}
+	wheres, whereBinds, err := d.ToSqlWhere(c)
+	if err != nil {
+		return
+	}
+	if len(c.JoinClause.JoinItems) > 0 {
+		return d.toSqlUpdateJoin(c, tables, sets, setBinds, wheres, whereBinds)
+	}
+	top, tail, tailBinds, err := d.toSqlMutationLimit(c)
+	if err != nil {
+		return
+	}
+	values = append(append(append(values, setBinds...), whereBinds...), tailBinds...)
+	sql4prepare = joinSql("UPDATE", top, tables, "SET", sets, wheres, tail)
+	return
+}
+
+func (d Driver) toSqlUpdateJoin(c *builder.Context, tables, sets string, setBinds []any, wheres string, whereBinds []any) (sql4prepare string, values []any, err error) {
+	items, err := d.toSqlJoinMutation(c, "update")
+	if err != nil {
+		return
+	}
+	switch d.Dialect.MutationJoin("update") {
+	case "join":
+		joins, joinBinds := joinItemsSql(items)
+		values = append(append(append(values, joinBinds...), setBinds...), whereBinds...)
+		sql4prepare = joinSql("UPDATE", tables, joins, "SET", sets, wheres)
+	case "target":
+		target, err := d.mutationTarget(c)
+		if err != nil {
+			return sql4prepare, values, err
+		}
+		joins, joinBinds := joinItemsSql(items)
+		values = append(append(append(values, setBinds...), joinBinds...), whereBinds...)
+		sql4prepare = joinSql("UPDATE", target, "SET", sets, "FROM", tables, joins, wheres)
+	case "from":
+		from, where, binds := joinItemsFrom(items, wheres, whereBinds)
+		values = append(append(values, setBinds...), binds...)
+		sql4prepare = joinSql("UPDATE", tables, "SET", sets, "FROM", from, where)
+	}
+	return
+}
+
+// toSqlMutationDelete 组合 DELETE 语句
+func (d Driver) toSqlMutationDelete(c *builder.Context) (sql4prepare string, values []any, err error) {
+	tables, _, err := d.ToSqlTable(c)
+	if err != nil {
+		return
+	}
+	wheres, whereBinds, err := d.ToSqlWhere(c)
+	if err != nil {
+		return
+	}
+	if len(c.JoinClause.JoinItems) == 0 {
+		top, tail, tailBinds, err := d.toSqlMutationLimit(c)
+		if err != nil {
+			return sql4prepare, values, err
+		}
+		values = append(append(values, whereBinds...), tailBinds...)
+		sql4prepare = joinSql("DELETE", top, "FROM", tables, wheres, tail)
+		return sql4prepare, values, err
+	}
+	items, err := d.toSqlJoinMutation(c, "delete")
+	if err != nil {
+		return
+	}
+	switch d.Dialect.MutationJoin("delete") {
+	case "join", "target":
+		target, err := d.mutationTarget(c)
+		if err != nil {
+			return sql4prepare, values, err
+		}
+		joins, joinBinds := joinItemsSql(items)
+		values = append(append(values, joinBinds...), whereBinds...)
+		sql4prepare = joinSql("DELETE", target, "FROM", tables, joins, wheres)
+	case "from":
+		from, where, binds := joinItemsFrom(items, wheres, whereBinds)
+		values = binds
+		sql4prepare = joinSql("DELETE FROM", tables, "USING", from, where)
+	}
+	return
+}
+
+// toSqlJoinMutation UPDATE/DELETE 的 join, 不支持 JOIN 或者同时有 ORDER BY/LIMIT 时返回错误
+func (d Driver) toSqlJoinMutation(c *builder.Context, statement string) (items []joinItem, err error) {
+	style := d.Dialect.MutationJoin(statement)
+	if style == "" {
+		return items, fmt.Errorf("%s with join is not supported by the dialect", statement)
+	}
+	if len(c.OrderByClause.Columns) > 0 || c.LimitOffsetClause.Limit > 0 || c.LimitOffsetClause.Offset > 0 {
+		return items, fmt.Errorf("%s with join does not support order by or limit", statement)
+	}
+	if items, err = d.toSqlJoinItems(c); err != nil {
+		return
+	}
+	for _, item := range items {
+		if style == "from" && item.joinType != "INNER JOIN" {
+			return items, fmt.Errorf("%s with join only supports inner join on the dialect", statement)
+		}
+	}
+	return
+}
+
+// toSqlMutationLimit UPDATE/DELETE 的 top 和末尾的 ORDER BY ... LIMIT
+func (d Driver) toSqlMutationLimit(c *builder.Context) (top, tail string, binds []any, err error) {
+	if c.LimitOffsetClause.Offset > 0 || c.LimitOffsetClause.Page > 1 {
+		return top, tail, binds, errors.New("update/delete does not support offset")
+	}
+	var limit = c.LimitOffsetClause.Limit
+	orderBy, binds := d.toSqlOrderBy(c)
+	if orderBy == "" && limit == 0 {
+		return
+	}
+	top, suffix, ordered := d.Dialect.MutationLimit(limit)
+	if orderBy != "" && !ordered {
+		return top, tail, binds, errors.New("update/delete with order by is not supported by the dialect")
+	}
+	if limit > 0 && top == "" && suffix == "" {
+		return top, tail, binds, errors.New("update/delete with limit is not supported by the dialect")
+	}
+	tail = joinSql(orderBy, suffix)
+	return
+}
+
+// mutationTarget UPDATE/DELETE 的目标表, 有别名时为别名
+func (d Driver) mutationTarget(c *builder.Context) (string, error) {
+	if c.TableClause.Alias != "" {
+		return d.Dialect.QuoteIdentifier(c.TableClause.Alias), nil
+	}
+	if table := TableName(c.TableClause, c.Prefix); table != "" {
+		return d.Dialect.QuoteIdentifier(table), nil
+	}
+	return "", errors.New("update/delete target table required")
+}
+
+// joinItemsSql INNER JOIN b ON ... 形式的 join
+func joinItemsSql(items []joinItem) (sql4prepare string, binds []any) {
+	var tmp []string
+	for _, item := range items {
+		if item.joinType == "" {
+			tmp = append(tmp, item.table)
+		} else {
+			tmp = append(tmp, fmt.Sprintf("%s %s ON %s", item.joinType, item.table, item.on))
+		}
+		binds = append(append(binds, item.tableBinds...), item.onBinds...)
+	}
+	return strings.Join(tmp, " "), binds
+}
+
+// joinItemsFrom FROM/USING b, c WHERE on1 AND on2 AND (wheres) 形式的 join
+func joinItemsFrom(items []joinItem, wheres string, whereBinds []any) (from, where string, binds []any) {
+	var tables, conditions []string
+	var onBinds []any
+	for _, item := range items {
+		tables = append(tables, item.table)
+		conditions = append(conditions, item.on)
+		binds = append(binds, item.tableBinds...)
+		onBinds = append(onBinds, item.onBinds...)
+	}
+	if wheres = strings.TrimPrefix(wheres, "WHERE "); wheres != "" {
+		conditions = append(conditions, fmt.Sprintf("(%s)", wheres))
+	}
+	binds = append(append(binds, onBinds...), whereBinds...)
+	return strings.Join(tables, ", "), "WHERE " + strings.Join(conditions, " AND "), binds
+}
+
+// joinSql 以空格连接非空的 sql 片段
+func joinSql(segments ...string) string {
+	var tmp []string
+	for _, segment := range segments {
+		if segment = strings.TrimSpace(segment); segment != "" {
+			tmp = append(tmp, segment)
+		}
+	}
+	return strings.Join(tmp, " ")
+}
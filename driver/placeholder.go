@@ -0,0 +1,143 @@
+package driver
+
+import (
+	"fmt"
+	"github.com/gohouse/gorose/v3/builder"
+	"strconv"
+	"strings"
+)
+
+// 生成 sql 时统一使用中性占位符 ?, 整条语句生成完毕后由 Renumber 按方言统一编号一次,
+// 这样子查询, union, join 等任意嵌套的语句在 postgresql($n), mssql/oracle(@pn) 下编号也是连续的;
+// 原生 sql 片段中不是占位符的 ?(如 postgresql jsonb 的 ?, ?|, ?& 操作符)先替换为 literalMark, 不参与编号
+
+// literalMark 字面量 ? 的临时标记, Renumber 时还原为 ?
+const literalMark = "\x00"
+
+// Renumber 将语句中引号外的中性占位符 ? 按出现顺序替换为方言的占位符, 如 $1, $2 或 @p1, @p2
+func (d Driver) Renumber(sql4prepare string) string {
+	if d.Dialect.New().Placeholder() == "?" {
+		return strings.ReplaceAll(sql4prepare, literalMark, "?")
+	}
+	var ph = d.Dialect.New()
+	var b strings.Builder
+	b.Grow(len(sql4prepare) + 16)
+	for i := 0; i < len(sql4prepare); i++ {
+		c := sql4prepare[i]
+		switch c {
+		case '\'', '"', '`', '[':
+			end := quoteEnd(sql4prepare, i)
+			b.WriteString(sql4prepare[i:end])
+			i = end - 1
+		case '?':
+			b.WriteString(ph.Placeholder())
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return strings.ReplaceAll(b.String(), literalMark, "?")
+}
+
+// neutralize Renumber 的逆操作, 将已编号的占位符还原为 ?, 用于嵌入外层语句的子查询;
+// 绑定参数按占位符的出现顺序重排, 同一个编号出现多次(如 $1 ... $1)时重复绑定; 引号外原有的 ? 为字面量
+func (d Driver) neutralize(sql4prepare string, binds []any) (string, []any, error) {
+	var prefix = strings.TrimSuffix(d.Dialect.New().Placeholder(), "1")
+	if prefix == "?" {
+		return sql4prepare, binds, nil
+	}
+	var b strings.Builder
+	b.Grow(len(sql4prepare))
+	var ordered []any
+	var numbered bool
+	for i := 0; i < len(sql4prepare); i++ {
+		c := sql4prepare[i]
+		switch {
+		case c == '\'' || c == '"' || c == '`' || c == '[':
+			end := quoteEnd(sql4prepare, i)
+			b.WriteString(sql4prepare[i:end])
+			i = end - 1
+		case c == '?':
+			b.WriteString(literalMark)
+		case strings.HasPrefix(sql4prepare[i:], prefix) && (i == 0 || !isIdentByte(sql4prepare[i-1])):
+			end := i + len(prefix)
+			for end < len(sql4prepare) && sql4prepare[end] >= '0' && sql4prepare[end] <= '9' {
+				end++
+			}
+			n, err := strconv.Atoi(sql4prepare[i+len(prefix) : end])
+			if err != nil {
+				b.WriteByte(c)
+				continue
+			}
+			if n < 1 || n > len(binds) {
+				return "", nil, fmt.Errorf("placeholder %s out of range of %d binds", sql4prepare[i:end], len(binds))
+			}
+			numbered = true
+			ordered = append(ordered, binds[n-1])
+			b.WriteByte('?')
+			i = end - 1
+		default:
+			b.WriteByte(c)
+		}
+	}
+	if !numbered {
+		ordered = binds
+	}
+	return b.String(), ordered, nil
+}
+
+// subQuery 生成子查询, 占位符还原为 ?, 由外层语句统一编号
+func (d Driver) subQuery(b builder.IBuilder) (sql4prepare string, binds []any, err error) {
+	sql4prepare, binds, err = b.ToSql()
+	if err != nil {
+		return
+	}
+	return d.neutralize(sql4prepare, binds)
+}
+
+// placeholder 值对应的占位符和绑定参数, 值为 builder.Expr 时原样输出表达式
+func placeholder(value any) (sql4prepare string, binds []any) {
+	if expr, ok := value.(builder.Expr); ok {
+		return rawSql(expr.Sql, expr.Binds), expr.Binds
+	}
+	return "?", []any{value}
+}
+
+// rawSql 嵌入语句的原生 sql 片段(WhereRaw, SelectRaw, Expr 等):
+// 没有绑定参数时原样输出, 其中的 ? 均为字面量;
+// 有绑定参数时 ? 为占位符, ?| 和 ?& 为 jsonb 操作符, ?? 表示字面量 ?, 如: WhereRaw("meta ?? ? AND id = ?", []any{"vip", 1})
+func rawSql(sql string, binds []any) string {
+	if !strings.Contains(sql, "?") {
+		return sql
+	}
+	if len(binds) == 0 {
+		return literal(sql)
+	}
+	var b strings.Builder
+	b.Grow(len(sql))
+	for i := 0; i < len(sql); i++ {
+		c := sql[i]
+		switch c {
+		case '\'', '"', '`', '[':
+			end := quoteEnd(sql, i)
+			b.WriteString(sql[i:end])
+			i = end - 1
+		case '?':
+			if i+1 < len(sql) && sql[i+1] == '?' {
+				b.WriteString(literalMark)
+				i++
+			} else if i+1 < len(sql) && (sql[i+1] == '|' || sql[i+1] == '&') {
+				b.WriteString(literalMark)
+			} else {
+				b.WriteByte(c)
+			}
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// literal 不含占位符的用户输入片段(如操作符), 其中的 ? 均为字面量
+func literal(s string) string {
+	return strings.ReplaceAll(s, "?", literalMark)
+}
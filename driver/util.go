@@ -3,6 +3,7 @@ package driver
 import (
 	"encoding/json"
 	"fmt"
+	"github.com/gohouse/gorose/v3/driver/dialect"
 	"reflect"
 	"regexp"
 	"runtime"
@@ -119,3 +120,32 @@ func Map[Data any, Result any](datas []Data, mapper func(Data) Result) []Result
 	}
 	return results
 }
+
+// quoteDotted 按 . 分段加引号, 如 users.id => "users"."id", users.* => "users".*, 已加引号的原样返回
+func (d Driver) quoteDotted(identifier string) string {
+	if identifier == "" || identifier == "*" || identifier[0] == d.Dialect.QuoteIdentifier("x")[0] {
+		return identifier
+	}
+	var parts = strings.Split(identifier, ".")
+	for i, part := range parts {
+		parts[i] = d.Dialect.QuoteIdentifier(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// dottedDialect 按 . 分段加引号的方言, 用于带 JOIN 的 UPDATE/DELETE 中 table.column 形式的列名
+type dottedDialect struct {
+	dialect.IDialect
+}
+
+func (d dottedDialect) QuoteIdentifier(identifier string) string {
+	return Driver{Dialect: d.IDialect}.quoteDotted(identifier)
+}
+
+// dotted 列名按 . 分段加引号的 Driver, 仅用于 WhereColumn, WhereDate 和带 JOIN 的 UPDATE/DELETE
+func (d Driver) dotted() Driver {
+	if _, ok := d.Dialect.(dottedDialect); ok {
+		return d
+	}
+	return Driver{Dialect: dottedDialect{d.Dialect}}
+}
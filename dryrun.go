@@ -0,0 +1,75 @@
+package gorose
+
+import (
+	"github.com/gohouse/gorose/v3/driver/dialect"
+	"sync"
+)
+
+// dryRunLog 预演模式下收集的写操作语句
+type dryRunLog struct {
+	mu    sync.Mutex
+	items []SqlItem
+}
+
+func (l *dryRunLog) add(item SqlItem) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.items = append(l.items, item)
+}
+
+func (l *dryRunLog) all() []SqlItem {
+	if l == nil {
+		return nil
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return append([]SqlItem(nil), l.items...)
+}
+
+// dryRunResult 预演模式下写操作的结果, 影响行数和自增id均为 0
+type dryRunResult struct{}
+
+func (dryRunResult) LastInsertId() (int64, error) { return 0, nil }
+func (dryRunResult) RowsAffected() (int64, error) { return 0, nil }
+
+// DryRun 开启预演模式, 之后创建的 Engin/Database 都不执行写操作
+// (Insert/Update/Delete/Truncate/Increment/Decrement 以及原生 Exec), 只收集语句, 通过 DryRunSqls() 获取;
+// 查询语句照常执行, 事务不会真正开启
+func (g *GoRose) DryRun() *GoRose {
+	g.dryRunLog = &dryRunLog{}
+	return g
+}
+
+// DryRunSqls 预演模式下所有 Engin/Database 收集的语句
+func (g *GoRose) DryRunSqls() []SqlItem {
+	return g.dryRunLog.all()
+}
+
+// DryRun 当前 Engin 开启预演模式, 参考 GoRose.DryRun()
+func (s *Engin) DryRun() *Engin {
+	s.dryRun = &dryRunLog{}
+	return s
+}
+
+// DryRunSqls 预演模式下收集的语句
+func (s *Engin) DryRunSqls() []SqlItem {
+	return s.dryRun.all()
+}
+
+// DryRun 同 Engin.DryRun
+func (db *Database) DryRun() *Database {
+	db = db.builder()
+	db.Engin.DryRun()
+	return db
+}
+
+// pretend 预演模式下收集语句, 返回是否处于预演模式
+func (s *Engin) pretend(query string, args []any) bool {
+	if s.dryRun == nil {
+		return false
+	}
+	var item = SqlItem{Sql: query, Bindings: args, dialect: dialect.GetDialect(s.driver)}
+	s.lastSql = item
+	s.dryRun.add(item)
+	return true
+}
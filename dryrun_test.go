@@ -0,0 +1,43 @@
+package gorose
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestDatabase_DryRun(t *testing.T) {
+	g, fake := newFake(t)
+	var db = g.NewDatabase().DryRun()
+
+	err := db.Transaction(func(tx TxHandler) error {
+		if _, err := tx().Table("users").Insert(map[string]any{"name": "john"}); err != nil {
+			return err
+		}
+		if _, err := tx().Table("users").Where("id", 1).Increment("views"); err != nil {
+			return err
+		}
+		_, err := tx().Exec("DELETE FROM logs")
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := fake.executed.Load(); got != 0 {
+		t.Errorf("dry run should not execute, got %d executions", got)
+	}
+	var sqls = db.DryRunSqls()
+	if len(sqls) != 3 || sqls[2].Sql != "DELETE FROM logs" || strings.TrimSpace(sqls[0].String()) != "INSERT INTO `users` (`name`) VALUES ('john')" {
+		t.Errorf("unexpected collected statements: %v", sqls)
+	}
+	if len(g.NewDatabase().DryRunSqls()) != 0 {
+		t.Error("dry run on database should not affect others")
+	}
+
+	g.DryRun()
+	if _, err = g.NewDatabase().Table("users").Truncate(); err != nil {
+		t.Fatal(err)
+	}
+	if sqls = g.DryRunSqls(); len(sqls) != 1 || sqls[0].Sql != "TRUNCATE TABLE `users`" {
+		t.Errorf("unexpected collected statements: %v", sqls)
+	}
+}
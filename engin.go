@@ -4,133 +4,388 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	"github.com/gohouse/gorose/v3/driver"
+	"github.com/gohouse/gorose/v3/driver/dialect"
 	"github.com/gohouse/gorose/v3/parser"
-	"log/slog"
 	"reflect"
+	"time"
 )
 
 type SqlItem struct {
 	Sql      string
 	Bindings []any
 	Err      error
+	Duration time.Duration // 执行耗时
+	Rows     int64         // 影响行数, 或者绑定的结果行数, 未知时为 -1
+	Node     string        // 执行的数据库节点, 如 master#0, slave#1
+
+	dialect dialect.IDialect
+}
+
+// String 将绑定值按方言替换到占位符中, 得到可以直接复制到数据库客户端执行的 sql
+//
+// 仅用于调试, 不能用于拼接执行的 sql
+func (s SqlItem) String() string {
+	return driver.Interpolate(s.dialect, s.Sql, s.Bindings)
 }
+
 type Engin struct {
 	*GoRose
 	tx            *sql.Tx
+	txDB          *sql.DB              // 开启事务的连接池
+	txNode        string               // 开启事务的数据库节点
+	txStmts       map[string]*sql.Stmt // 当前事务中的预处理语句
 	autoSavePoint uint8
 	lastSql       SqlItem
+	tenantSafe    bool
+	ctx           context.Context // 调用方的 context, 见 WithContext
+	txCtx         context.Context // 事务 span 所在的 context
+	txSpan        Span
+	dryRun        *dryRunLog    // 预演模式下收集的语句
+	recorder      *Recorder     // 语句记录器, 见 Record()
+	txCallbacks   []txCallbacks // 每层事务注册的回调, 见 AfterCommit()
 }
 
 func NewEngin(g *GoRose) *Engin {
-	return &Engin{GoRose: g}
+	return &Engin{GoRose: g, dryRun: g.dryRunLog}
 }
 
+// LastSql 最后执行的一条语句
 func (s *Engin) LastSql() SqlItem {
-	if !slog.Default().Enabled(context.Background(), slog.LevelDebug) {
-		return SqlItem{Err: errors.New("only record when slog level in debug mod")}
-	}
 	return s.lastSql
 }
 
+// Log 以 debug 级别记录一条语句, 并作为 LastSql
 func (s *Engin) Log(sqls string, bindings ...any) {
-	if slog.Default().Enabled(context.Background(), slog.LevelDebug) {
-		slog.With("bindings", bindings).Debug(sqls)
-		s.lastSql = SqlItem{Sql: sqls, Bindings: bindings}
+	s.observe(SqlItem{Sql: sqls, Bindings: bindings, Rows: -1})
+}
+
+// TenantSafe 标记下一条原生语句(Exec/Query/QueryRow/QueryTo)已自行处理了租户隔离, 多租户模式下才允许执行;
+// 标记只对下一条语句有效, 如: engin.TenantSafe().Exec("DELETE FROM logs WHERE tenant_id = ?", 3)
+func (s *Engin) TenantSafe() *Engin {
+	s.tenantSafe = true
+	return s
+}
+
+// tenantGuard 多租户模式下拒绝未标记的原生语句, 并清除 TenantSafe 标记
+func (s *Engin) tenantGuard() error {
+	if s.tenantColumn == "" {
+		return nil
+	}
+	if !s.tenantSafe {
+		return ErrTenantUnsafe
 	}
+	s.tenantSafe = false
+	return nil
 }
 
 func (s *Engin) execute(query string, args ...any) (int64, error) {
-	exec, err := s.Exec(query, args...)
+	exec, err := s.exec(query, args...)
 	if err != nil {
 		return 0, err
 	}
 	return exec.RowsAffected()
 }
 func (s *Engin) Exec(query string, args ...any) (sql.Result, error) {
-	s.Log(query, args...)
+	if err := s.tenantGuard(); err != nil {
+		return nil, err
+	}
+	return s.exec(query, args...)
+}
+func (s *Engin) exec(query string, args ...any) (res sql.Result, err error) {
+	if s.pretend(query, args) {
+		return dryRunResult{}, nil
+	}
+	var item = SqlItem{Sql: query, Bindings: args, Rows: -1}
+	ctx, done := s.trace(query)
+	res, item.Node, err = s.doExec(ctx, query, args...)
+	if err == nil {
+		item.Rows, _ = res.RowsAffected()
+	}
+	item.Err = err
+	done(item)
+	return
+}
+func (s *Engin) doExec(ctx context.Context, query string, args ...any) (res sql.Result, node string, err error) {
+	db, node := s.pickMaster()
 	if s.tx != nil {
-		return s.tx.Exec(query, args...)
+		node = s.txNode
+	}
+	stmt, release, err := s.prepare(ctx, db, query)
+	if err != nil {
+		return
+	}
+	if stmt != nil {
+		defer release()
+		res, err = stmt.ExecContext(ctx, args...)
+	} else if s.tx != nil {
+		res, err = s.tx.ExecContext(ctx, query, args...)
+	} else {
+		res, err = db.ExecContext(ctx, query, args...)
 	}
-	return s.MasterDB().Exec(query, args...)
+	err = dialect.ClassifyError(s.driver, err)
+	return
 }
 func (s *Engin) Begin() (err error) {
+	return s.BeginTx(nil)
+}
+
+// BeginTx 开启事务, opts 指定隔离级别和是否只读, 只读事务在从库上执行
+//
+// 已在事务中时创建保存点(嵌套事务), opts 被忽略; 内层 Rollback 只回滚到保存点, 内层 Commit 释放保存点
+func (s *Engin) BeginTx(opts *sql.TxOptions) (err error) {
+	if s.dryRun != nil && s.tx == nil {
+		return
+	}
 	if s.tx != nil {
 		s.autoSavePoint += 1
-		return s.SavePoint(s.autoSavePoint)
+		s.pushTxCallbacks()
+		return s.SavePoint(savePointName(s.autoSavePoint))
 	}
-	s.tx, err = s.MasterDB().Begin()
+	if opts != nil && opts.ReadOnly {
+		s.txDB, s.txNode = s.pickSlave()
+	} else {
+		s.txDB, s.txNode = s.pickMaster()
+	}
+	s.tx, err = s.txDB.BeginTx(s.traceTx(), opts)
+	if err != nil {
+		s.traceTxEnd("begin", err)
+		s.tx, s.txDB = nil, nil
+		return
+	}
+	s.pushTxCallbacks()
 	return
 }
+
+// savePointName 嵌套事务自动生成的保存点名
+func savePointName(level uint8) string {
+	return fmt.Sprintf("gorose_sp_%d", level)
+}
+
+// SavePoint 创建保存点, 名称由方言加引号
 func (s *Engin) SavePoint(name any) (err error) {
-	_, err = s.tx.Exec("SAVEPOINT ?", name)
-	return
+	return s.execTx(dialect.GetDialect(s.driver).SavePoint(fmt.Sprint(name)))
 }
+
+// RollbackTo 回滚到保存点
 func (s *Engin) RollbackTo(name any) (err error) {
-	_, err = s.tx.Exec("ROLLBACK TO SAVEPOINT ?", name)
+	return s.execTx(dialect.GetDialect(s.driver).RollbackTo(fmt.Sprint(name)))
+}
+
+// ReleaseSavePoint 释放保存点, 数据库不支持时忽略
+func (s *Engin) ReleaseSavePoint(name any) (err error) {
+	if query := dialect.GetDialect(s.driver).ReleaseSavePoint(fmt.Sprint(name)); query != "" {
+		err = s.execTx(query)
+	}
 	return
 }
+
+// execTx 在当前事务上直接执行保存点等事务控制语句, 不使用预处理语句
+func (s *Engin) execTx(query string) (err error) {
+	if s.pretend(query, nil) {
+		return
+	}
+	if s.tx == nil {
+		return sql.ErrTxDone
+	}
+	ctx, done := s.trace(query)
+	_, err = s.tx.ExecContext(ctx, query)
+	err = dialect.ClassifyError(s.driver, err)
+	done(SqlItem{Sql: query, Err: err, Rows: -1, Node: s.txNode})
+	return
+}
+
+// Rollback 回滚事务, 嵌套事务中只回滚到当前层的保存点
 func (s *Engin) Rollback() (err error) {
+	if s.dryRun != nil && s.tx == nil {
+		return
+	}
 	if s.autoSavePoint > 0 {
 		// decrease in advance whether rollbackTo fail
 		currentPoint := s.autoSavePoint
 		s.autoSavePoint -= 1
-		return s.RollbackTo(currentPoint)
+		s.popTxCallbacks(false)
+		return s.RollbackTo(savePointName(currentPoint))
 	}
+	// 无论成功与否, 事务都已结束
 	err = s.tx.Rollback()
-	if err != nil {
-		return
-	}
-	s.tx = nil
+	s.traceTxEnd("rollback", err)
+	s.tx, s.txDB, s.txStmts = nil, nil, nil
+	s.fireTxCallbacks(false)
 	return
 }
+
+// Commit 提交事务, 嵌套事务中只释放当前层的保存点
 func (s *Engin) Commit() (err error) {
-	if s.autoSavePoint > 0 {
-		s.autoSavePoint -= 1
+	if s.dryRun != nil && s.tx == nil {
 		return
 	}
-	err = s.tx.Commit()
-	if err != nil {
-		return
+	if s.autoSavePoint > 0 {
+		currentPoint := s.autoSavePoint
+		s.autoSavePoint -= 1
+		s.popTxCallbacks(true)
+		return s.ReleaseSavePoint(savePointName(currentPoint))
 	}
-	s.tx = nil
+	err = dialect.ClassifyError(s.driver, s.tx.Commit())
+	s.traceTxEnd("commit", err)
+	s.tx, s.txDB, s.txStmts = nil, nil, nil
+	// 提交失败时事务没有生效, 按回滚处理
+	s.fireTxCallbacks(err == nil)
 	return
 }
+
+// Transaction 在事务中执行闭包, 闭包返回错误时回滚;
+// 设置了重试策略(GoRose.UseRetry)时, 最外层事务遇到可重试的错误会在新的事务中重新执行所有闭包
 func (s *Engin) Transaction(closure ...func(*Engin) error) (err error) {
-	if err = s.Begin(); err != nil {
+	return s.TransactionTx(nil, closure...)
+}
+
+// TransactionTx 同 Transaction, opts 参考 BeginTx
+func (s *Engin) TransactionTx(opts *sql.TxOptions, closure ...func(*Engin) error) (err error) {
+	if s.tx != nil {
+		return s.transaction(opts, closure...)
+	}
+	return s.withRetry(func() error { return s.transaction(opts, closure...) })
+}
+func (s *Engin) transaction(opts *sql.TxOptions, closure ...func(*Engin) error) (err error) {
+	if err = s.BeginTx(opts); err != nil {
 		return
 	}
 	for _, v := range closure {
 		err = v(s)
 		if err != nil {
-			return s.Rollback()
+			if err2 := s.Rollback(); err2 != nil {
+				return errors.Join(err, err2)
+			}
+			return
 		}
 	}
 	return s.Commit()
 }
 
 func (s *Engin) Query(query string, args ...any) (rows *sql.Rows, err error) {
-	s.Log(query, args...)
+	if err = s.tenantGuard(); err != nil {
+		return
+	}
+	return s.query(query, args...)
+}
+func (s *Engin) query(query string, args ...any) (rows *sql.Rows, err error) {
+	err = s.withRetryRead(func() (err error) {
+		ctx, done := s.trace(query)
+		var node string
+		rows, node, err = s.doQuery(ctx, query, args...)
+		done(SqlItem{Sql: query, Bindings: args, Err: err, Rows: -1, Node: node})
+		return
+	})
+	return
+}
+func (s *Engin) doQuery(ctx context.Context, query string, args ...any) (rows *sql.Rows, node string, err error) {
+	db, node := s.pickSlave()
 	if s.tx != nil {
-		return s.tx.Query(query, args...)
+		node = s.txNode
+	}
+	stmt, release, err := s.prepare(ctx, db, query)
+	if err != nil {
+		return
+	}
+	if stmt != nil {
+		defer release()
+		rows, err = stmt.QueryContext(ctx, args...)
+	} else if s.tx != nil {
+		rows, err = s.tx.QueryContext(ctx, query, args...)
 	} else {
-		return s.SlaveDB().Query(query, args...)
+		rows, err = db.QueryContext(ctx, query, args...)
+	}
+	err = dialect.ClassifyError(s.driver, err)
+	return
+}
+
+// Row QueryRow 的结果, 同 sql.Row; 语句被拒绝执行(如多租户模式下未标记 TenantSafe)时, Scan/Err 返回该错误
+type Row struct {
+	row *sql.Row
+	err error
+}
+
+func (r *Row) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Scan(dest...)
+}
+func (r *Row) Err() error {
+	if r.err != nil {
+		return r.err
 	}
+	return r.row.Err()
 }
 
-func (s *Engin) QueryRow(query string, args ...any) *sql.Row {
-	s.Log(query, args...)
+func (s *Engin) QueryRow(query string, args ...any) *Row {
+	if err := s.tenantGuard(); err != nil {
+		return &Row{err: err}
+	}
+	return &Row{row: s.queryRow(query, args...)}
+}
+func (s *Engin) queryRow(query string, args ...any) (row *sql.Row) {
+	_ = s.withRetryRead(func() error {
+		row = s.doQueryRow(query, args...)
+		return row.Err()
+	})
+	return
+}
+func (s *Engin) doQueryRow(query string, args ...any) (row *sql.Row) {
+	ctx, done := s.trace(query)
+	db, node := s.pickSlave()
 	if s.tx != nil {
-		return s.tx.QueryRow(query, args...)
+		node = s.txNode
+	}
+	if stmt, release, err := s.prepare(ctx, db, query); err == nil && stmt != nil {
+		defer release()
+		row = stmt.QueryRowContext(ctx, args...)
+	} else if s.tx != nil {
+		row = s.tx.QueryRowContext(ctx, query, args...)
 	} else {
-		return s.SlaveDB().QueryRow(query, args...)
+		row = db.QueryRowContext(ctx, query, args...)
 	}
+	done(SqlItem{Sql: query, Bindings: args, Err: row.Err(), Rows: -1, Node: node})
+	return
 }
+
 func (s *Engin) QueryTo(bind any, query string, args ...any) (err error) {
-	var rows *sql.Rows
-	if rows, err = s.Query(query, args...); err != nil {
+	if err = s.tenantGuard(); err != nil {
 		return
 	}
-	return s.rowsToBind(rows, bind)
+	return s.queryTo(bind, query, args...)
+}
+func (s *Engin) queryTo(bind any, query string, args ...any) (err error) {
+	rfv := reflect.Indirect(reflect.ValueOf(bind))
+	var before int
+	if rfv.Kind() == reflect.Slice {
+		before = rfv.Len()
+	}
+	return s.withRetryRead(func() error {
+		resetBind(rfv, before)
+		return s.queryToOnce(bind, query, args...)
+	})
+}
+func (s *Engin) queryToOnce(bind any, query string, args ...any) (err error) {
+	var item = SqlItem{Sql: query, Bindings: args, Rows: -1}
+	ctx, done := s.trace(query)
+	rfv := reflect.Indirect(reflect.ValueOf(bind))
+	var before int
+	if rfv.Kind() == reflect.Slice {
+		before = rfv.Len()
+	}
+	var rows *sql.Rows
+	if rows, item.Node, err = s.doQuery(ctx, query, args...); err == nil {
+		err = s.rowsToBind(rows, bind)
+	}
+	if rfv.Kind() == reflect.Slice {
+		item.Rows = int64(rfv.Len() - before)
+	}
+	item.Err = err
+	done(item)
+	return
 }
 func (s *Engin) rowsToBind(rows *sql.Rows, bind any) (err error) {
 	rfv := reflect.Indirect(reflect.ValueOf(bind))
@@ -155,8 +410,7 @@ func (s *Engin) rowsToBind(rows *sql.Rows, bind any) (err error) {
 }
 
 func (s *Engin) rowsToStruct(rows *sql.Rows, rfv reflect.Value) error {
-	//FieldTag, FieldStruct, _ := structsParse(rfv)
-	FieldTag, FieldStruct, _ := parser.StructsTypeParse(rfv.Type())
+	meta := parser.Meta(rfv.Type())
 
 	defer rows.Close()
 
@@ -165,50 +419,39 @@ func (s *Engin) rowsToStruct(rows *sql.Rows, rfv reflect.Value) error {
 		return err
 	}
 
-	// 列的个数
-	count := len(columns)
+	// 列对应的字段, 每次查询只匹配一次, 不存在的列为 nil
+	fields := make([]*parser.FieldMeta, len(columns))
+	for i, column := range columns {
+		fields[i] = meta.Columns[column]
+	}
+	// 一条数据的各列的值的地址, 各行复用
+	valPointers := make([]any, len(columns))
 
 	for rows.Next() {
 		if rfv.Kind() == reflect.Slice {
-			rfvItem := reflect.Indirect(reflect.New(rfv.Type().Elem()))
-			err = s.scanStructRow(rfvItem, rows, count, FieldTag, FieldStruct, columns)
-			if err != nil {
-				return err
-			}
-			rfv.Set(reflect.Append(rfv, rfvItem))
+			rfv.Set(reflect.Append(rfv, reflect.Zero(rfv.Type().Elem())))
+			err = s.scanStructRow(rfv.Index(rfv.Len()-1), rows, fields, valPointers)
 		} else {
-			err = s.scanStructRow(rfv, rows, count, FieldTag, FieldStruct, columns)
-			if err != nil {
-				return err
-			}
+			err = s.scanStructRow(rfv, rows, fields, valPointers)
+		}
+		if err != nil {
+			return err
 		}
 	}
-	return nil
+	return rows.Err()
 }
-func (s *Engin) scanStructRow(rfv reflect.Value, rows *sql.Rows, count int, FieldTag, FieldStruct, columns []string) error {
-	// 一条数据的各列的值的地址
-	valPointers := make([]any, count)
-	for i, v1 := range columns {
+func (s *Engin) scanStructRow(rfv reflect.Value, rows *sql.Rows, fields []*parser.FieldMeta, valPointers []any) error {
+	for i, field := range fields {
 		var valueField reflect.Value
-		// 比对字段
-		var fieldExists bool
-		for i2, v2 := range FieldTag {
-			if v1 == v2 {
-				valueField = rfv.FieldByName(FieldStruct[i2])
-				fieldExists = true
-				break
-			}
+		if field != nil {
+			valueField = parser.FieldByIndex(rfv, field.Index, true)
 		}
-		if !fieldExists {
+		if !valueField.IsValid() {
 			var value any
 			valPointers[i] = &value
 			continue
 		}
-		if valueField.CanAddr() {
-			valPointers[i] = valueField.Addr().Interface()
-		} else {
-			valPointers[i] = valueField
-		}
+		valPointers[i] = field.ScanTo(valueField)
 	}
 	return rows.Scan(valPointers...)
 }
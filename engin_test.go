@@ -0,0 +1,227 @@
+package gorose
+
+import (
+	"context"
+	"database/sql"
+	sqldriver "database/sql/driver"
+	"fmt"
+	"github.com/gohouse/gorose/v3/driver/dialect"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeDriver 内存驱动, 每次查询返回 fakeFixture.rows 行固定数据, 用于测试和基准测试结果绑定
+type fakeDriver struct{}
+type fakeConn struct{ fixture *fakeFixture }
+type fakeStmt struct{ fixture *fakeFixture }
+type fakeResult struct{}
+type fakeTx struct{}
+type fakeRows struct {
+	columns []string
+	cursor  int
+	total   int
+}
+
+// fakeFixture 内存驱动的状态, 每个测试通过 newFake 获得独立的一份, 按 dsn 区分, 互不影响
+type fakeFixture struct {
+	rows     int          // 每次查询返回的行数
+	prepared atomic.Int64 // fakeConn.Prepare 调用次数
+	queried  atomic.Int64 // fakeConn.QueryContext 调用次数
+	executed atomic.Int64 // fakeConn.ExecContext 调用次数
+	failures atomic.Int64 // 之后的 n 次 ExecContext/QueryContext 返回 fakeDeadlock
+}
+
+// fakeRowsDefault 未指定行数时每次查询返回的行数
+const fakeRowsDefault = 3
+
+var fakeFixtures sync.Map // dsn => *fakeFixture
+var fakeSeq atomic.Int64
+
+// newFake 为当前测试注册独立的驱动状态, 返回连接到该状态的 GoRose, 测试结束时关闭
+func newFake(tb testing.TB) (*GoRose, *fakeFixture) {
+	var fixture = &fakeFixture{rows: fakeRowsDefault}
+	var dsn = fmt.Sprintf("%s#%d", tb.Name(), fakeSeq.Add(1))
+	fakeFixtures.Store(dsn, fixture)
+	var g = Open("gorose_fake", dsn)
+	tb.Cleanup(func() {
+		_ = g.Close()
+		fakeFixtures.Delete(dsn)
+	})
+	return g, fixture
+}
+
+// fakeDeadlock 模拟 mysql 驱动的死锁错误
+type fakeDeadlock struct {
+	Number uint16
+}
+
+func (fakeDeadlock) Error() string { return "Error 1213: Deadlock found when trying to get lock" }
+
+func (f *fakeFixture) fail() error {
+	if f.failures.Add(-1) >= 0 {
+		return &fakeDeadlock{Number: 1213}
+	}
+	f.failures.Store(0)
+	return nil
+}
+
+func init() {
+	sql.Register("gorose_fake", fakeDriver{})
+	dialect.Register("gorose_fake", &dialect.MySQLDialect{})
+}
+
+// Open 未通过 newFake 注册的 dsn 使用默认状态
+func (fakeDriver) Open(dsn string) (sqldriver.Conn, error) {
+	if fixture, ok := fakeFixtures.Load(dsn); ok {
+		return fakeConn{fixture.(*fakeFixture)}, nil
+	}
+	return fakeConn{&fakeFixture{rows: fakeRowsDefault}}, nil
+}
+
+func (c fakeConn) Prepare(string) (sqldriver.Stmt, error) {
+	c.fixture.prepared.Add(1)
+	return fakeStmt(c), nil
+}
+func (fakeConn) Close() error                 { return nil }
+func (fakeConn) Begin() (sqldriver.Tx, error) { return fakeTx{}, nil }
+func (fakeConn) BeginTx(context.Context, sqldriver.TxOptions) (sqldriver.Tx, error) {
+	return fakeTx{}, nil
+}
+func (c fakeConn) QueryContext(_ context.Context, query string, _ []sqldriver.NamedValue) (sqldriver.Rows, error) {
+	c.fixture.queried.Add(1)
+	if err := c.fixture.fail(); err != nil {
+		return nil, err
+	}
+	if strings.Contains(query, "count(") {
+		return &fakeRows{columns: []string{"count"}, total: 1}, nil
+	}
+	if strings.Contains(query, "empty") { // 表名包含 empty 时返回空结果
+		return &fakeRows{columns: c.fixture.newRows().columns}, nil
+	}
+	if strings.Contains(query, "json") { // 表名包含 json 时返回 json 列
+		return &fakeRows{columns: []string{"id", "meta", "tags", "options"}, total: 2}, nil
+	}
+	return c.fixture.newRows(), nil
+}
+func (c fakeConn) ExecContext(context.Context, string, []sqldriver.NamedValue) (sqldriver.Result, error) {
+	c.fixture.executed.Add(1)
+	if err := c.fixture.fail(); err != nil {
+		return nil, err
+	}
+	return fakeResult{}, nil
+}
+
+func (fakeStmt) Close() error                                      { return nil }
+func (fakeStmt) NumInput() int                                     { return -1 }
+func (fakeStmt) Exec([]sqldriver.Value) (sqldriver.Result, error)  { return fakeResult{}, nil }
+func (s fakeStmt) Query([]sqldriver.Value) (sqldriver.Rows, error) { return s.fixture.newRows(), nil }
+
+func (fakeTx) Commit() error   { return nil }
+func (fakeTx) Rollback() error { return nil }
+
+func (fakeResult) LastInsertId() (int64, error) { return 1, nil }
+func (fakeResult) RowsAffected() (int64, error) { return 1, nil }
+
+func (f *fakeFixture) newRows() *fakeRows {
+	return &fakeRows{columns: []string{"id", "created_at", "title", "body", "views", "author.id", "author.name"}, total: f.rows}
+}
+
+func (r *fakeRows) Columns() []string { return r.columns }
+func (r *fakeRows) Close() error      { return nil }
+func (r *fakeRows) Next(dest []sqldriver.Value) error {
+	if r.cursor >= r.total {
+		return io.EOF
+	}
+	r.cursor++
+	dest[0] = int64(r.cursor)
+	if len(dest) == 1 {
+		return nil
+	}
+	if r.columns[1] == "meta" {
+		dest[1] = []byte(`{"city":"Berlin"}`)
+		dest[2] = `["go","orm"]`
+		dest[3] = nil
+		return nil
+	}
+	dest[1] = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	dest[2] = []byte("gorose")
+	dest[3] = []byte("laravel query builder for go")
+	dest[4] = int64(r.cursor * 10)
+	dest[5] = int64(r.cursor % 100)
+	dest[6] = []byte("john")
+	return nil
+}
+
+type benchBase struct {
+	Id        int64     `db:"id,pk"`
+	CreatedAt time.Time `db:"created_at"`
+}
+type benchPost struct {
+	benchBase
+	Title  string `db:"title"`
+	Body   string `db:"body"`
+	Views  int64  `db:"views"`
+	Author User   `db:"author"`
+}
+
+func TestEngin_QueryToStruct(t *testing.T) {
+	g, _ := newFake(t)
+	var posts []benchPost
+	err := g.NewEngin().QueryTo(&posts, "SELECT * FROM posts")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != fakeRowsDefault {
+		t.Fatalf("expect %d rows, got %d", fakeRowsDefault, len(posts))
+	}
+	var last = posts[len(posts)-1]
+	if last.Id != fakeRowsDefault || last.Title != "gorose" || last.Views != fakeRowsDefault*10 || last.Author.Name != "john" || last.CreatedAt.Year() != 2024 {
+		t.Errorf("unexpected row: %+v", last)
+	}
+}
+
+func TestEngin_QueryToJson(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+	}
+	type UsersJson struct {
+		Id      int64    `db:"id,pk"`
+		Meta    Address  `db:"meta,json"`
+		Tags    []string `db:"tags,json"`
+		Options *Address `db:"options,json"`
+	}
+	g, _ := newFake(t)
+	var users []UsersJson
+	err := g.NewEngin().QueryTo(&users, "SELECT * FROM users_json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 2 {
+		t.Fatalf("expect 2 rows, got %d", len(users))
+	}
+	var u = users[1]
+	if u.Id != 2 || u.Meta.City != "Berlin" || len(u.Tags) != 2 || u.Tags[1] != "orm" || u.Options != nil {
+		t.Errorf("unexpected row: %+v", u)
+	}
+}
+
+func BenchmarkEngin_QueryToStruct(b *testing.B) {
+	g, fixture := newFake(b)
+	fixture.rows = 100000
+	var engin = g.NewEngin()
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var posts []benchPost
+		if err := engin.QueryTo(&posts, "SELECT * FROM posts"); err != nil {
+			b.Fatal(err)
+		}
+		if len(posts) != fixture.rows {
+			b.Fatalf("expect %d rows, got %d", fixture.rows, len(posts))
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package gorose
+
+import (
+	"errors"
+	"github.com/gohouse/gorose/v3/driver/dialect"
+)
+
+// ErrTenantUnsafe 多租户模式下, 未经 TenantSafe() 标记的原生语句拒绝执行
+var ErrTenantUnsafe = errors.New("raw statement refused in tenant mode, mark it with TenantSafe() if it is tenant-safe")
+
+// ErrStaleObject 乐观锁更新失败, 数据已被其他请求修改(version 不匹配)
+var ErrStaleObject = errors.New("stale object: the record has been modified by others")
+
+// ErrRecordNotFound First/Find 没有查询到数据
+var ErrRecordNotFound = errors.New("record not found")
+
+// 归一化的驱动错误, 执行语句返回的驱动错误会按方言识别并包装为 *DbError, 如:
+//
+//	_, err := db.Table("users").Insert(user)
+//	if errors.Is(err, gorose.ErrDuplicateKey) {
+//		var dbErr *gorose.DbError
+//		errors.As(err, &dbErr) // dbErr.Constraint: 冲突的唯一索引名
+//	}
+//
+// 自定义驱动通过 dialect.RegisterErrorClassifier 注册识别方法
+var (
+	ErrDuplicateKey = dialect.ErrDuplicateKey
+	ErrForeignKey   = dialect.ErrForeignKey
+	ErrNotNull      = dialect.ErrNotNull
+	ErrDeadlock     = dialect.ErrDeadlock
+)
+
+// DbError 归一化后的驱动错误, 参考 dialect.DbError
+type DbError = dialect.DbError
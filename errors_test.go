@@ -0,0 +1,65 @@
+package gorose
+
+import (
+	"errors"
+	"github.com/gohouse/gorose/v3/driver/dialect"
+	"testing"
+)
+
+// fakeMySQLError 模拟 mysql 驱动的 MySQLError
+type fakeMySQLError struct {
+	Number  uint16
+	Message string
+}
+
+func (e *fakeMySQLError) Error() string { return e.Message }
+
+// fakePgError 模拟 pgx 的 PgError
+type fakePgError struct {
+	Code           string
+	ConstraintName string
+}
+
+func (e *fakePgError) Error() string    { return "pg error " + e.Code }
+func (e *fakePgError) SQLState() string { return e.Code }
+
+func TestClassifyError(t *testing.T) {
+	var cases = []struct {
+		driver     string
+		err        error
+		kind       error
+		constraint string
+	}{
+		{"mysql", &fakeMySQLError{1062, "Duplicate entry 'a@b.c' for key 'users.email_unique'"}, ErrDuplicateKey, "users.email_unique"},
+		{"mysql", &fakeMySQLError{1452, "Cannot add or update a child row: a foreign key constraint fails (`test`.`posts`, CONSTRAINT `posts_user_fk` FOREIGN KEY (`user_id`) REFERENCES `users` (`id`))"}, ErrForeignKey, "posts_user_fk"},
+		{"mysql", &fakeMySQLError{1048, "Column 'name' cannot be null"}, ErrNotNull, "name"},
+		{"mysql", &fakeMySQLError{1213, "Deadlock found when trying to get lock"}, ErrDeadlock, ""},
+		{"postgresql", &fakePgError{Code: "23505", ConstraintName: "users_email_key"}, ErrDuplicateKey, "users_email_key"},
+		{"postgresql", errors.Join(errors.New("rollback failed"), &fakePgError{Code: "23505", ConstraintName: "users_email_key"}), ErrDuplicateKey, "users_email_key"},
+		{"sqlite3", errors.New("NOT NULL constraint failed: users.name"), ErrNotNull, "users.name"},
+		{"oracle", errors.New("ORA-00001: unique constraint (SCOTT.PK_EMP) violated"), ErrDuplicateKey, "SCOTT.PK_EMP"},
+	}
+	for _, c := range cases {
+		err := dialect.ClassifyError(c.driver, c.err)
+		var dbErr *DbError
+		if !errors.Is(err, c.kind) || !errors.As(err, &dbErr) || dbErr.Constraint != c.constraint {
+			t.Errorf("%s: %v classified as %#v", c.driver, c.err, err)
+		}
+		if errors.Unwrap(err) != c.err {
+			t.Errorf("%s: driver error should be preserved", c.driver)
+		}
+	}
+	if err := dialect.ClassifyError("mysql", &fakeMySQLError{1146, "Table 'test.foo' doesn't exist"}); errors.As(err, new(*DbError)) {
+		t.Errorf("unknown errors should not be wrapped: %v", err)
+	}
+}
+
+func TestDatabase_FirstNotFound(t *testing.T) {
+	g, _ := newFake(t)
+	if _, err := g.NewDatabase().Table("empty_posts").First(); !errors.Is(err, ErrRecordNotFound) {
+		t.Errorf("expect ErrRecordNotFound, got %v", err)
+	}
+	if v, err := g.NewDatabase().Table("empty_posts").Value("title"); err != nil || v != nil {
+		t.Errorf("Value should return nil without error, got %v, %v", v, err)
+	}
+}
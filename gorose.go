@@ -2,6 +2,9 @@ package gorose
 
 import (
 	"database/sql"
+	"fmt"
+	"log/slog"
+	"time"
 )
 
 type GoRose struct {
@@ -10,6 +13,17 @@ type GoRose struct {
 	slave   []*sql.DB
 	driver  string
 	prefix  string
+	// 多租户字段名, 为空表示未开启多租户模式
+	tenantColumn string
+	stmtCache    *stmtCache
+	cache        Cache
+	// 日志和监控, 见 observe.go
+	logger        *slog.Logger
+	slowThreshold time.Duration
+	metrics       Metrics
+	tracer        Tracer
+	dryRunLog     *dryRunLog // 预演模式, 见 DryRun()
+	retryPolicy   *RetryPolicy
 	//handlers HandlersChain
 }
 
@@ -86,6 +100,9 @@ func Open(conf ...any) *GoRose {
 }
 
 func (g *GoRose) Close() (err error) {
+	if g.stmtCache != nil {
+		g.stmtCache.close()
+	}
 	if len(g.master) > 0 {
 		for _, db := range g.master {
 			err = db.Close()
@@ -100,16 +117,59 @@ func (g *GoRose) Close() (err error) {
 }
 
 func (g *GoRose) MasterDB() *sql.DB {
+	db, _ := g.pickMaster()
+	return db
+}
+func (g *GoRose) SlaveDB() *sql.DB {
+	db, _ := g.pickSlave()
+	return db
+}
+
+// pickMaster 随机选择一个主库, node 为节点名, 如 master#0
+func (g *GoRose) pickMaster() (db *sql.DB, node string) {
 	if len(g.master) == 0 {
-		return nil
+		return
 	}
-	return g.master[GetRandomInt(len(g.master))]
+	i := GetRandomInt(len(g.master))
+	return g.master[i], fmt.Sprintf("master#%d", i)
 }
-func (g *GoRose) SlaveDB() *sql.DB {
+
+// pickSlave 随机选择一个从库, 没有从库时使用主库
+func (g *GoRose) pickSlave() (db *sql.DB, node string) {
 	if len(g.slave) == 0 {
-		return g.MasterDB()
+		return g.pickMaster()
 	}
-	return g.slave[GetRandomInt(len(g.slave))]
+	i := GetRandomInt(len(g.slave))
+	return g.slave[i], fmt.Sprintf("slave#%d", i)
+}
+
+// TenantMode 开启多租户模式
+//
+//	column: 租户字段名, 如 tenant_id
+//
+// 开启后, 所有 Database 构建的 SELECT/UPDATE/DELETE 都会自动带上 column = ? 条件, INSERT 自动填充该字段,
+// 租户值通过 Database.Tenant() 指定; 原生的 Exec/Query 每条都必须通过 Engin.TenantSafe() 显式标记后才允许执行
+func (g *GoRose) TenantMode(column string) *GoRose {
+	g.tenantColumn = column
+	return g
+}
+
+// StmtCache 开启预处理语句缓存, 每个连接池(*sql.DB)最多缓存 size 条, 超出时淘汰最久未使用的语句并关闭
+//
+// 相同的 sql 文本复用同一个 *sql.Stmt, 事务中通过 tx.Stmt() 使用
+func (g *GoRose) StmtCache(size int) *GoRose {
+	if size > 0 {
+		g.stmtCache = newStmtCache(size)
+	}
+	return g
+}
+
+// StmtCacheStats 预处理语句缓存的命中统计
+func (g *GoRose) StmtCacheStats() (stats StmtCacheStats) {
+	if g.stmtCache != nil {
+		stats = g.stmtCache.Stats()
+	}
+	return
 }
 
 func (g *GoRose) NewDatabase() *Database {
@@ -0,0 +1,110 @@
+package gorose
+
+import (
+	"context"
+	"github.com/gohouse/gorose/v3/driver/dialect"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Metrics 语句执行监控, 可以自行实现对接 prometheus 等, 如:
+//
+//	type promMetrics struct {
+//		count   *prometheus.CounterVec   // labels: operation, table, status
+//		latency *prometheus.HistogramVec // labels: operation, table
+//	}
+//	func (m promMetrics) ObserveQuery(operation, table string, duration time.Duration, err error) {
+//		status := "ok"
+//		if err != nil {
+//			status = "error"
+//		}
+//		m.count.WithLabelValues(operation, table, status).Inc()
+//		m.latency.WithLabelValues(operation, table).Observe(duration.Seconds())
+//	}
+type Metrics interface {
+	// ObserveQuery 每条语句执行完毕后调用, operation 如 select/insert/update/delete, table 未知时为空
+	ObserveQuery(operation, table string, duration time.Duration, err error)
+}
+
+// UseLogger 设置日志, 默认使用 slog.Default()
+func (g *GoRose) UseLogger(l *slog.Logger) *GoRose {
+	g.logger = l
+	return g
+}
+
+// SlowThreshold 慢查询阈值, 执行耗时超过阈值的语句以 warn 级别记录, 不受 debug 级别限制; 0 为不开启
+func (g *GoRose) SlowThreshold(d time.Duration) *GoRose {
+	g.slowThreshold = d
+	return g
+}
+
+// UseMetrics 设置语句执行监控
+func (g *GoRose) UseMetrics(m Metrics) *GoRose {
+	g.metrics = m
+	return g
+}
+
+func (g *GoRose) getLogger() *slog.Logger {
+	if g.logger != nil {
+		return g.logger
+	}
+	return slog.Default()
+}
+
+// observe 记录执行的语句: 保存为 LastSql, 写日志, 上报监控
+func (s *Engin) observe(item SqlItem) {
+	item.dialect = dialect.GetDialect(s.driver)
+	s.lastSql = item
+	if r := s.getRecorder(); r != nil {
+		r.Record(item)
+	}
+
+	logger := s.getLogger()
+	level := slog.LevelDebug
+	if item.Err != nil {
+		level = slog.LevelError
+	} else if s.slowThreshold > 0 && item.Duration >= s.slowThreshold {
+		level = slog.LevelWarn
+	}
+	if logger.Enabled(context.Background(), level) {
+		attrs := []slog.Attr{
+			slog.Any("bindings", item.Bindings),
+			slog.Duration("duration", item.Duration),
+			slog.Int64("rows", item.Rows),
+		}
+		if item.Node != "" {
+			attrs = append(attrs, slog.String("node", item.Node))
+		}
+		if item.Err != nil {
+			attrs = append(attrs, slog.Any("error", item.Err))
+		}
+		msg := item.Sql
+		if level == slog.LevelWarn {
+			msg = "slow query: " + item.Sql
+		}
+		logger.LogAttrs(context.Background(), level, msg, attrs...)
+	}
+
+	if s.metrics != nil {
+		operation, table := sqlOperation(item.Sql)
+		s.metrics.ObserveQuery(operation, table, item.Duration, item.Err)
+	}
+}
+
+var sqlTableRegexp = regexp.MustCompile(`(?i)\b(?:from|into|update|table)\s+([` + "`" + `"\[]?[\w.]+[` + "`" + `"\]]?)`)
+
+// sqlOperation 从语句中解析操作类型和主表名, 用于监控分组
+func sqlOperation(query string) (operation, table string) {
+	query = strings.TrimSpace(query)
+	if i := strings.IndexFunc(query, func(r rune) bool { return r == ' ' || r == '\n' || r == '\t' || r == '(' }); i > 0 {
+		operation = strings.ToLower(query[:i])
+	} else {
+		operation = strings.ToLower(query)
+	}
+	if m := sqlTableRegexp.FindStringSubmatch(query); m != nil {
+		table = strings.Trim(m[1], "`\"[]")
+	}
+	return
+}
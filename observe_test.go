@@ -0,0 +1,53 @@
+package gorose
+
+import (
+	"bytes"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeMetrics struct {
+	operations []string
+}
+
+func (m *fakeMetrics) ObserveQuery(operation, table string, duration time.Duration, err error) {
+	m.operations = append(m.operations, operation+":"+table)
+}
+
+func TestGoRose_Observe(t *testing.T) {
+	var buf bytes.Buffer
+	var metrics = &fakeMetrics{}
+	g, fake := newFake(t)
+	g.UseLogger(slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelInfo}))).
+		UseMetrics(metrics)
+
+	var engin = g.NewEngin()
+	if _, err := engin.Exec("UPDATE `users` SET `age` = ? WHERE `id` = ?", 20, 1); err != nil {
+		t.Fatal(err)
+	}
+	if last := engin.LastSql(); last.Rows != 1 || last.Node != "master#0" || len(last.Bindings) != 2 {
+		t.Errorf("unexpected last sql: %+v", last)
+	}
+	if buf.Len() != 0 {
+		t.Errorf("debug statements should not be logged at info level: %s", buf.String())
+	}
+
+	g.SlowThreshold(time.Nanosecond)
+	var posts []benchPost
+	if err := engin.QueryTo(&posts, "SELECT * FROM posts"); err != nil {
+		t.Fatal(err)
+	}
+	if last := engin.LastSql(); last.Rows != int64(fake.rows) {
+		t.Errorf("expect %d rows, got %d", fake.rows, last.Rows)
+	}
+	if !strings.Contains(buf.String(), "level=WARN") || !strings.Contains(buf.String(), "slow query: SELECT * FROM posts") {
+		t.Errorf("expect slow query warning, got: %s", buf.String())
+	}
+
+	var expect = []string{"update:users", "select:posts"}
+	if strings.Join(metrics.operations, ",") != strings.Join(expect, ",") {
+		t.Errorf("expect metrics %v, got %v", expect, metrics.operations)
+	}
+}
@@ -0,0 +1,17 @@
+module github.com/gohouse/gorose/v3/otel
+
+go 1.22
+
+require (
+	github.com/gohouse/gorose/v3 v3.0.0
+	go.opentelemetry.io/otel v1.28.0
+	go.opentelemetry.io/otel/trace v1.28.0
+)
+
+require (
+	github.com/go-logr/logr v1.4.2 // indirect
+	github.com/go-logr/stdr v1.2.2 // indirect
+	go.opentelemetry.io/otel/metric v1.28.0 // indirect
+)
+
+replace github.com/gohouse/gorose/v3 => ../
@@ -0,0 +1,74 @@
+// Package otel 将 gorose.Tracer 适配到 opentelemetry, 使用方式:
+//
+//	g := gorose.Open(...).UseTracer(otel.NewTracer(nil))
+//	db := g.NewDatabase().WithContext(ctx)
+//
+// 独立的 module, 核心包不依赖 opentelemetry
+package otel
+
+import (
+	"context"
+	"fmt"
+	"github.com/gohouse/gorose/v3"
+	otelapi "go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const instrumentationName = "github.com/gohouse/gorose/v3"
+
+// Tracer 实现了 gorose.Tracer
+type Tracer struct {
+	tracer trace.Tracer
+}
+
+// NewTracer tp 为空时使用全局的 TracerProvider
+func NewTracer(tp trace.TracerProvider) *Tracer {
+	if tp == nil {
+		tp = otelapi.GetTracerProvider()
+	}
+	return &Tracer{tracer: tp.Tracer(instrumentationName)}
+}
+
+func (t *Tracer) Start(ctx context.Context, name string, attrs ...gorose.TraceAttr) (context.Context, gorose.Span) {
+	ctx, span := t.tracer.Start(ctx, name, trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(toAttributes(attrs)...))
+	return ctx, Span{span: span}
+}
+
+// Span 实现了 gorose.Span
+type Span struct {
+	span trace.Span
+}
+
+func (s Span) SetAttributes(attrs ...gorose.TraceAttr) {
+	s.span.SetAttributes(toAttributes(attrs)...)
+}
+
+func (s Span) RecordError(err error) {
+	s.span.RecordError(err)
+	s.span.SetStatus(codes.Error, err.Error())
+}
+
+func (s Span) End() {
+	s.span.End()
+}
+
+func toAttributes(attrs []gorose.TraceAttr) []attribute.KeyValue {
+	var kvs = make([]attribute.KeyValue, 0, len(attrs))
+	for _, attr := range attrs {
+		switch v := attr.Value.(type) {
+		case string:
+			kvs = append(kvs, attribute.String(attr.Key, v))
+		case int64:
+			kvs = append(kvs, attribute.Int64(attr.Key, v))
+		case int:
+			kvs = append(kvs, attribute.Int(attr.Key, v))
+		case bool:
+			kvs = append(kvs, attribute.Bool(attr.Key, v))
+		default:
+			kvs = append(kvs, attribute.String(attr.Key, fmt.Sprint(v)))
+		}
+	}
+	return kvs
+}
@@ -0,0 +1,231 @@
+package parser
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// FieldMeta 结构体字段元数据
+type FieldMeta struct {
+	Name    string   // 结构体字段路径, 嵌入或嵌套结构体以 . 分隔, 如 BaseModel.Id
+	Column  string   // 数据库字段名
+	Index   []int    // 字段索引路径, 用于 FieldByIndex
+	Options []string // tag 选项, 如 pk, version
+	Nested  bool     // 是否为嵌套结构体的字段, 只用于查询结果绑定
+
+	// ToDB 写入数据库前的值转换, 默认处理 driver.Valuer
+	ToDB func(field reflect.Value) (any, error)
+	// ScanTo 查询结果绑定的扫描目标, 默认为字段地址
+	ScanTo func(field reflect.Value) any
+}
+
+// StructMeta 结构体元数据, 按 reflect.Type 缓存, 见 Meta()
+type StructMeta struct {
+	Type      reflect.Type
+	TableName string
+	Fields    []*FieldMeta          // 数据库字段, 不含嵌套结构体字段
+	Scans     []*FieldMeta          // 查询结果绑定字段, 含嵌套结构体字段
+	Columns   map[string]*FieldMeta // 列名 => 字段, 含嵌套结构体字段
+	Pk        *FieldMeta
+	Version   *FieldMeta
+
+	tags  []string // Fields 的字段名, 见 StructsTypeParse
+	names []string // Fields 的结构体字段路径
+}
+
+var metaCache sync.Map // map[reflect.Type]*StructMeta
+
+// Meta 获取结构体的元数据, 并发安全, 每个类型只解析一次
+//
+//	rft: struct 或者 struct slice 的类型
+func Meta(rft reflect.Type) *StructMeta {
+	for rft.Kind() == reflect.Slice || rft.Kind() == reflect.Ptr {
+		rft = rft.Elem()
+	}
+	if v, ok := metaCache.Load(rft); ok {
+		return v.(*StructMeta)
+	}
+	var meta = &StructMeta{Type: rft, TableName: structsToTableName(rft), Columns: map[string]*FieldMeta{}}
+	meta.Scans = parseFields(rft, "", "", nil, false, nil)
+	for _, field := range meta.Scans {
+		if _, ok := meta.Columns[field.Column]; !ok {
+			meta.Columns[field.Column] = field
+		}
+		if field.Nested {
+			continue
+		}
+		meta.Fields = append(meta.Fields, field)
+		meta.tags = append(meta.tags, field.Column)
+		meta.names = append(meta.names, field.Name)
+		if meta.Pk == nil && slices.Contains(field.Options, "pk") {
+			meta.Pk = field
+		}
+		if meta.Version == nil && slices.Contains(field.Options, "version") {
+			meta.Version = field
+		}
+	}
+	meta.tags, meta.names = slices.Clip(meta.tags), slices.Clip(meta.names)
+	v, _ := metaCache.LoadOrStore(rft, meta)
+	return v.(*StructMeta)
+}
+
+// FieldByName 根据结构体字段路径获取字段元数据
+func (m *StructMeta) FieldByName(name string) *FieldMeta {
+	for _, field := range m.Fields {
+		if field.Name == name {
+			return field
+		}
+	}
+	return nil
+}
+
+var (
+	scannerType = reflect.TypeOf((*sql.Scanner)(nil)).Elem()
+	valuerType  = reflect.TypeOf((*driver.Valuer)(nil)).Elem()
+	timeType    = reflect.TypeOf(time.Time{})
+)
+
+// isNestedStruct 是否为嵌套的关联结构体, 而不是 time.Time, sql.NullString 等字段值类型
+func isNestedStruct(rft reflect.Type) bool {
+	return rft.Kind() == reflect.Struct && rft != timeType &&
+		!reflect.PointerTo(rft).Implements(scannerType) && !rft.Implements(valuerType)
+}
+
+// parseFields 解析结构体字段
+//
+// 嵌入结构体的字段会被展开, 嵌入字段的 tag 作为前缀, 如: BaseModel `db:"base_"`;
+// 嵌套结构体字段以 tag.子字段 的形式展开, 并标记为 Nested;
+// visited 为正在展开的结构体类型, 引用自身的字段(如 Parent *Category, Children []Category)是关联数据, 不是数据库字段, 直接跳过
+func parseFields(rft reflect.Type, tagPrefix, namePrefix string, index []int, nested bool, visited []reflect.Type) (fields []*FieldMeta) {
+	visited = append(slices.Clip(visited), rft)
+	for i := 0; i < rft.NumField(); i++ {
+		field := rft.Field(i)
+		tag := field.Tag.Get("db")
+		if tag == "-" || field.Name == "TableName" {
+			continue
+		}
+		tags := strings.Split(tag, ",")
+		var fieldIndex = append(slices.Clip(index), i)
+		var fieldType = field.Type
+		if fieldType.Kind() == reflect.Ptr {
+			fieldType = fieldType.Elem()
+		}
+		if isSelfReference(fieldType, visited) {
+			continue
+		}
+		if field.Anonymous {
+			if fieldType.Kind() == reflect.Struct {
+				fields = append(fields, parseFields(fieldType, tagPrefix+tags[0], namePrefix+field.Name+".", fieldIndex, nested, visited)...)
+			}
+			continue
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if tags[0] == "" {
+			tags[0] = field.Name
+		}
+		var isJson = slices.Contains(tags[1:], "json")
+		if !isJson && isNestedStruct(fieldType) {
+			fields = append(fields, parseFields(fieldType, tagPrefix+tags[0]+".", namePrefix+field.Name+".", fieldIndex, true, visited)...)
+			continue
+		}
+		var meta = &FieldMeta{
+			Name:    namePrefix + field.Name,
+			Column:  tagPrefix + tags[0],
+			Index:   fieldIndex,
+			Options: tags[1:],
+			Nested:  nested,
+			ToDB:    valueToDB,
+			ScanTo:  fieldAddr,
+		}
+		if isJson {
+			meta.ToDB, meta.ScanTo = jsonToDB, jsonScanTo
+		}
+		fields = append(fields, meta)
+	}
+	return
+}
+
+// isSelfReference 字段类型(或切片元素类型)是否为正在展开的结构体
+func isSelfReference(rft reflect.Type, visited []reflect.Type) bool {
+	for rft.Kind() == reflect.Slice || rft.Kind() == reflect.Array || rft.Kind() == reflect.Ptr {
+		rft = rft.Elem()
+	}
+	return rft.Kind() == reflect.Struct && slices.Contains(visited, rft)
+}
+
+func valueToDB(field reflect.Value) (any, error) {
+	var rfvVal = field.Interface()
+	if v, ok := rfvVal.(driver.Valuer); ok {
+		return v.Value()
+	}
+	return rfvVal, nil
+}
+
+func fieldAddr(field reflect.Value) any {
+	return field.Addr().Interface()
+}
+
+// jsonToDB json 字段写入时序列化为 json 文本, nil 写入 NULL
+func jsonToDB(field reflect.Value) (any, error) {
+	switch field.Kind() {
+	case reflect.Ptr, reflect.Map, reflect.Slice, reflect.Interface:
+		if field.IsNil() {
+			return nil, nil
+		}
+	}
+	b, err := json.Marshal(field.Interface())
+	if err != nil {
+		return nil, err
+	}
+	return string(b), nil
+}
+
+// jsonScanTo json 字段查询结果反序列化到字段, NULL 保持零值
+func jsonScanTo(field reflect.Value) any {
+	return &jsonScanner{field: field.Addr().Interface()}
+}
+
+type jsonScanner struct {
+	field any
+}
+
+func (s *jsonScanner) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		return nil
+	case []byte:
+		return json.Unmarshal(v, s.field)
+	case string:
+		return json.Unmarshal([]byte(v), s.field)
+	default:
+		return fmt.Errorf("unsupported json scan type %T", src)
+	}
+}
+
+// FieldByIndex 根据字段索引路径获取结构体字段
+//
+// 路径中的 nil 指针, init 为 true 时自动初始化, 否则返回无效的 reflect.Value
+func FieldByIndex(rfv reflect.Value, index []int, init bool) reflect.Value {
+	for i, x := range index {
+		if i > 0 && rfv.Kind() == reflect.Ptr {
+			if rfv.IsNil() {
+				if !init || !rfv.CanSet() {
+					return reflect.Value{}
+				}
+				rfv.Set(reflect.New(rfv.Type().Elem()))
+			}
+			rfv = rfv.Elem()
+		}
+		rfv = rfv.Field(x)
+	}
+	return rfv
+}
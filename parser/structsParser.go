@@ -1,13 +1,16 @@
 package parser
 
 import (
-	"database/sql/driver"
 	"reflect"
 	"slices"
-	"strings"
 )
 
+// StructsToTableName 获取结构体对应的表名, 优先级: TableName 字段的 db tag > TableName() 方法 > 结构体名
 func StructsToTableName(rft reflect.Type) (tab string) {
+	return Meta(rft).TableName
+}
+
+func structsToTableName(rft reflect.Type) (tab string) {
 	if field, ok := rft.FieldByName("TableName"); ok {
 		if field.Tag.Get("db") != "" {
 			tab = field.Tag.Get("db")
@@ -37,49 +40,83 @@ func StructsParse(obj any) (FieldTag []string, FieldStruct []string, pkField str
 }
 
 func StructsTypeParse(rft reflect.Type) (fieldTag []string, fieldStruct []string, pkField string) {
-	//rfv := reflect.Indirect(reflect.ValueOf(obj))
+	if rft.Kind() == reflect.Slice && rft.Elem().Kind() != reflect.Struct {
+		return
+	}
+	meta := Meta(rft)
+	if meta.Pk != nil {
+		pkField = meta.Pk.Name
+	}
+	return meta.tags, meta.names, pkField
+}
+
+// StructsScanParse 同 StructsTypeParse, 但包含嵌套结构体字段, 用于查询结果绑定
+//
+//	type Post struct {
+//		Id   int64 `db:"id"`
+//		User User  `db:"user"`
+//	}
+//
+// 查询列 user.name (如: SELECT users.name AS "user.name") 会绑定到 Post.User.Name
+func StructsScanParse(rft reflect.Type) (fieldTag []string, fieldStruct []string) {
+	for _, field := range Meta(rft).Scans {
+		fieldTag = append(fieldTag, field.Column)
+		fieldStruct = append(fieldStruct, field.Name)
+	}
+	return
+}
+
+// StructsVersionField 获取乐观锁版本字段, 如: Version int64 `db:"version,version"`
+func StructsVersionField(rft reflect.Type) (versionTag string, versionField string) {
 	if rft.Kind() == reflect.Slice {
-		rft2 := rft.Elem()
-		if rft2.Kind() == reflect.Struct {
-			return StructsTypeParse(rft2)
-		}
-	} else {
-		for i := 0; i < rft.NumField(); i++ {
-			field := rft.Field(i)
-			if field.Anonymous {
-				continue
-			}
-			tag := field.Tag.Get("db")
-			if tag == "-" || field.Name == "TableName" {
-				continue
-			}
-			if tag == "" {
-				//field.Tag = reflect.StructTag("db:" + field.Name)
-				fieldStruct = append(fieldStruct, field.Name)
-				tag = field.Name
-			} else {
-				if strings.Contains(tag, ",") {
-					tags := strings.Split(tag, ",")
-					if slices.Contains(tags, "pk") {
-						pkField = field.Name
-						tag = tags[0]
-					}
-				}
-				fieldStruct = append(fieldStruct, field.Name)
-			}
-			//else {
-			//	fieldStruct = append(fieldStruct, field.Tag.Get("db"))
-			//}
-			//if field.Tag.Get("pkField") == "true" {
-			//	pkField = field.Name
-			//	pkValue = rfv.FieldByName(field.Name)
-			//}
-			fieldTag = append(fieldTag, tag)
+		rft = rft.Elem()
+	}
+	if rft.Kind() != reflect.Struct {
+		return
+	}
+	if version := Meta(rft).Version; version != nil {
+		return version.Column, version.Name
+	}
+	return
+}
+
+// StructToVersion 获取乐观锁版本字段的 tag 和当前值
+func StructToVersion(obj any) (versionTag string, versionValue any) {
+	rfv := reflect.Indirect(reflect.ValueOf(obj))
+	if rfv.Kind() != reflect.Struct {
+		return
+	}
+	if version := Meta(rfv.Type()).Version; version != nil {
+		versionTag = version.Column
+		if field := FieldByIndex(rfv, version.Index, false); field.IsValid() {
+			versionValue = field.Interface()
 		}
 	}
 	return
 }
 
+// StructVersionIncrement 更新成功后, 将结构体的乐观锁版本字段加1, obj 必须为指针
+func StructVersionIncrement(obj any) {
+	rfv := reflect.Indirect(reflect.ValueOf(obj))
+	if rfv.Kind() != reflect.Struct {
+		return
+	}
+	version := Meta(rfv.Type()).Version
+	if version == nil {
+		return
+	}
+	field := FieldByIndex(rfv, version.Index, false)
+	if !field.CanSet() {
+		return
+	}
+	switch field.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		field.SetInt(field.Int() + 1)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		field.SetUint(field.Uint() + 1)
+	}
+}
+
 //func StructsToSelects(obj any) []string {
 //	tag, fieldStruct, _ := StructsParse(obj)
 //	if len(tag) > 0 {
@@ -90,44 +127,32 @@ func StructsTypeParse(rft reflect.Type) (fieldTag []string, fieldStruct []string
 //}
 
 func StructDataToMap(rfv reflect.Value, tags, fieldStruct []string, mustColumn ...string) (data map[string]any, err error) {
-	data = make(map[string]any)
-	for i, fieldName := range fieldStruct {
-		field := rfv.FieldByName(fieldName)
-		if (field.Kind() == reflect.Ptr && field.IsNil()) || (field.IsZero() && !slices.Contains(mustColumn, tags[i])) {
-			continue
-		}
-		var rfvVal = field.Interface()
-		if v, ok := rfvVal.(driver.Valuer); ok {
-			var value driver.Value
-			value, err = v.Value()
-			if err != nil {
-				return
+	meta := Meta(rfv.Type())
+	var fields = meta.Fields
+	if !slices.Equal(tags, meta.tags) || !slices.Equal(fieldStruct, meta.names) {
+		fields = make([]*FieldMeta, 0, len(fieldStruct))
+		for i, fieldName := range fieldStruct {
+			if field := meta.FieldByName(fieldName); field != nil {
+				fields = append(fields, &FieldMeta{Column: tags[i], Index: field.Index, ToDB: field.ToDB})
 			}
-			data[tags[i]] = value
-		} else {
-			data[tags[i]] = rfvVal
 		}
 	}
-	return
+	return structFieldsToMap(rfv, fields, nil, mustColumn...)
 }
 
-func structUpdateDataToMap(rfv reflect.Value, tags, fieldStruct []string, pkField string, mustColumn ...string) (data map[string]any, err error) {
+// structFieldsToMap 结构体字段值转为 map, 忽略 nil 指针和零值字段(mustColumn 中的除外), 以及 skip 字段
+func structFieldsToMap(rfv reflect.Value, fields []*FieldMeta, skip *FieldMeta, mustColumn ...string) (data map[string]any, err error) {
 	data = make(map[string]any)
-	for i, fieldName := range fieldStruct {
-		field := rfv.FieldByName(fieldName)
-		if (field.Kind() == reflect.Ptr && field.IsNil()) || (field.IsZero() && !slices.Contains(mustColumn, tags[i])) || fieldName == pkField {
+	for _, fieldMeta := range fields {
+		if fieldMeta == skip {
 			continue
 		}
-		var rfvVal = field.Interface()
-		if v, ok := rfvVal.(driver.Valuer); ok {
-			var value driver.Value
-			value, err = v.Value()
-			if err != nil {
-				return
-			}
-			data[tags[i]] = value
-		} else {
-			data[tags[i]] = rfvVal
+		field := FieldByIndex(rfv, fieldMeta.Index, false)
+		if !field.IsValid() || (field.Kind() == reflect.Ptr && field.IsNil()) || (field.IsZero() && !slices.Contains(mustColumn, fieldMeta.Column)) {
+			continue
+		}
+		if data[fieldMeta.Column], err = fieldMeta.ToDB(field); err != nil {
+			return
 		}
 	}
 	return
@@ -136,8 +161,7 @@ func structUpdateDataToMap(rfv reflect.Value, tags, fieldStruct []string, pkFiel
 func StructToDelete(obj any, mustColumn ...string) (data map[string]any, err error) {
 	rfv := reflect.Indirect(reflect.ValueOf(obj))
 	if rfv.Kind() == reflect.Struct {
-		tag, fieldStruct, _ := StructsTypeParse(rfv.Type())
-		data, err = StructDataToMap(rfv, tag, fieldStruct, mustColumn...)
+		data, err = structFieldsToMap(rfv, Meta(rfv.Type()).Fields, nil, mustColumn...)
 	}
 	return
 }
@@ -146,18 +170,17 @@ func StructsToInsert(obj any, mustColumn ...string) (datas []map[string]any, err
 	rfv := reflect.Indirect(reflect.ValueOf(obj))
 	switch rfv.Kind() {
 	case reflect.Struct:
-		fieldTag, fieldStruct, _ := StructsTypeParse(rfv.Type())
 		var data = make(map[string]any)
-		data, err = StructDataToMap(rfv, fieldTag, fieldStruct, mustColumn...)
+		data, err = structFieldsToMap(rfv, Meta(rfv.Type()).Fields, nil, mustColumn...)
 		if err != nil {
 			return
 		}
 		datas = append(datas, data)
 	case reflect.Slice:
-		tag, fieldStruct, _ := StructsTypeParse(rfv.Type())
+		meta := Meta(rfv.Type())
 		for i := 0; i < rfv.Len(); i++ {
 			var data = make(map[string]any)
-			data, err = StructDataToMap(rfv.Index(i), tag, fieldStruct, mustColumn...)
+			data, err = structFieldsToMap(rfv.Index(i), meta.Fields, nil, mustColumn...)
 			if err != nil {
 				return
 			}
@@ -170,17 +193,21 @@ func StructsToInsert(obj any, mustColumn ...string) (datas []map[string]any, err
 }
 
 func StructToUpdate(obj any, mustColumn ...string) (data map[string]any, pkTag string, pkValue any, err error) {
-	tag, fieldStruct, pkField := StructsParse(obj)
-	if len(tag) > 0 {
-		data = make(map[string]any)
-		rfv := reflect.Indirect(reflect.ValueOf(obj))
-		data, err = structUpdateDataToMap(rfv, tag, fieldStruct, pkField, mustColumn...)
+	rfv := reflect.Indirect(reflect.ValueOf(obj))
+	if rfv.Kind() != reflect.Struct {
+		return
+	}
+	meta := Meta(rfv.Type())
+	if len(meta.Fields) > 0 {
+		data, err = structFieldsToMap(rfv, meta.Fields, meta.Pk, mustColumn...)
 		if err != nil {
 			return
 		}
-		if pkField != "" {
-			pkTag = tag[slices.Index(fieldStruct, pkField)]
-			pkValue = rfv.FieldByName(pkField).Interface()
+		if meta.Pk != nil {
+			pkTag = meta.Pk.Column
+			if field := FieldByIndex(rfv, meta.Pk.Index, false); field.IsValid() {
+				pkValue = field.Interface()
+			}
 		}
 	}
 
@@ -0,0 +1,143 @@
+package gorose
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Recorder 语句记录器, 按执行顺序保存最近 size 条语句(绑定值/耗时/错误),
+// 用于请求级的调试工具栏或测试断言; 可以绑定到 Engin/Database, 也可以通过 context 传递
+//
+//	rec := gorose.NewRecorder(100)
+//	ctx = gorose.WithRecorder(ctx, rec)
+//	db.WithContext(ctx).Table("users").Get()
+//	rec.Items()
+type Recorder struct {
+	size    int
+	mu      sync.Mutex
+	items   []SqlItem // 环形缓冲区
+	next    int       // 下一条写入的位置
+	total   int       // 记录过的总条数
+	dropped int       // 超出 size 被丢弃的条数
+}
+
+// NewRecorder 最多保存 size 条语句, 超出时丢弃最早的
+func NewRecorder(size int) *Recorder {
+	if size <= 0 {
+		size = 100
+	}
+	return &Recorder{size: size}
+}
+
+// Record 记录一条语句
+func (r *Recorder) Record(item SqlItem) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.items) < r.size {
+		r.items = append(r.items, item)
+	} else {
+		r.items[r.next] = item
+		r.dropped++
+	}
+	r.next = (r.next + 1) % r.size
+	r.total++
+}
+
+// Items 按执行顺序返回保存的语句
+func (r *Recorder) Items() []SqlItem {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.items) < r.size {
+		return append([]SqlItem(nil), r.items...)
+	}
+	return append(append([]SqlItem(nil), r.items[r.next:]...), r.items[:r.next]...)
+}
+
+// Total 记录过的总条数, 包括被丢弃的
+func (r *Recorder) Total() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.total
+}
+
+// Dropped 超出容量被丢弃的条数
+func (r *Recorder) Dropped() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.dropped
+}
+
+// Reset 清空记录
+func (r *Recorder) Reset() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.items, r.next, r.total, r.dropped = nil, 0, 0, 0
+}
+
+// RepeatedQuery 相同 sql 的重复执行统计
+type RepeatedQuery struct {
+	Sql      string
+	Count    int
+	Duration time.Duration // 累计耗时
+}
+
+// NPlusOne 检测 N+1 查询: 相同的 sql(绑定值不同)执行次数达到 threshold 的语句, 按次数从多到少排列
+func (r *Recorder) NPlusOne(threshold int) (repeated []RepeatedQuery) {
+	var index = map[string]int{}
+	for _, item := range r.Items() {
+		i, ok := index[item.Sql]
+		if !ok {
+			i = len(repeated)
+			index[item.Sql] = i
+			repeated = append(repeated, RepeatedQuery{Sql: item.Sql})
+		}
+		repeated[i].Count++
+		repeated[i].Duration += item.Duration
+	}
+	var res = repeated[:0]
+	for _, v := range repeated {
+		if v.Count >= threshold {
+			res = append(res, v)
+		}
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Count > res[j].Count })
+	return res
+}
+
+type recorderKey struct{}
+
+// WithRecorder 将记录器绑定到 context, 通过 WithContext(ctx) 执行的语句都会被记录
+func WithRecorder(ctx context.Context, r *Recorder) context.Context {
+	return context.WithValue(ctx, recorderKey{}, r)
+}
+
+// RecorderFromContext 获取 context 中的记录器
+func RecorderFromContext(ctx context.Context) *Recorder {
+	r, _ := ctx.Value(recorderKey{}).(*Recorder)
+	return r
+}
+
+// Record 将记录器绑定到当前 Engin, 优先于 context 中的记录器
+func (s *Engin) Record(r *Recorder) *Engin {
+	s.recorder = r
+	return s
+}
+
+// Record 同 Engin.Record
+func (db *Database) Record(r *Recorder) *Database {
+	db = db.builder()
+	db.Engin.Record(r)
+	return db
+}
+
+func (s *Engin) getRecorder() *Recorder {
+	if s.recorder != nil {
+		return s.recorder
+	}
+	if s.ctx != nil {
+		return RecorderFromContext(s.ctx)
+	}
+	return nil
+}
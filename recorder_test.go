@@ -0,0 +1,40 @@
+package gorose
+
+import (
+	"context"
+	"testing"
+)
+
+func TestRecorder(t *testing.T) {
+	var rec = NewRecorder(3)
+	var ctx = WithRecorder(context.Background(), rec)
+	g, _ := newFake(t)
+
+	for i := 0; i < 3; i++ {
+		if _, err := g.NewDatabase().WithContext(ctx).Table("users").Where("id", i).Count(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := g.NewEngin().WithContext(ctx).Exec("UPDATE users SET views = views + 1"); err != nil {
+		t.Fatal(err)
+	}
+
+	var items = rec.Items()
+	if len(items) != 3 || rec.Total() != 4 || rec.Dropped() != 1 {
+		t.Fatalf("unexpected recorder state: %d items, total %d, dropped %d", len(items), rec.Total(), rec.Dropped())
+	}
+	if items[2].Sql != "UPDATE users SET views = views + 1" || items[0].Bindings[0] != 1 {
+		t.Errorf("unexpected recorded order: %v", items)
+	}
+	if repeated := rec.NPlusOne(2); len(repeated) != 1 || repeated[0].Count != 2 {
+		t.Errorf("expect 1 repeated query executed twice, got %+v", repeated)
+	}
+
+	var own = NewRecorder(10)
+	if _, err := g.NewEngin().WithContext(ctx).Record(own).Exec("DELETE FROM logs"); err != nil {
+		t.Fatal(err)
+	}
+	if len(own.Items()) != 1 || rec.Total() != 4 {
+		t.Error("engin recorder should take precedence over context recorder")
+	}
+}
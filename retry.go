@@ -0,0 +1,74 @@
+package gorose
+
+import (
+	"github.com/gohouse/gorose/v3/driver/dialect"
+	"math/rand"
+	"reflect"
+	"time"
+)
+
+// RetryPolicy 瞬时错误(死锁, 序列化失败, 连接断开)的重试策略
+type RetryPolicy struct {
+	MaxAttempts int           // 最多执行次数, 包括第一次, 小于 2 时不重试
+	BaseDelay   time.Duration // 第一次重试前的等待时间, 之后每次翻倍
+	MaxDelay    time.Duration // 等待时间上限, 0 为不限制
+	// Retryable 判断错误是否可以重试, 为空时使用方言的判断, 参考 dialect.IsRetryable
+	Retryable func(err error) bool
+	// RetryReads 查询语句(Get/First/Count 等)是否也重试, 仅在事务外生效
+	RetryReads bool
+}
+
+// UseRetry 设置重试策略, Transaction 遇到可重试的错误时, 在新的事务中重新执行整个闭包
+func (g *GoRose) UseRetry(policy RetryPolicy) *GoRose {
+	g.retryPolicy = &policy
+	return g
+}
+
+// delay 第 attempt 次重试前的等待时间, 在 [d/2, d) 之间随机, 避免并发的事务同时重试再次冲突
+func (p *RetryPolicy) delay(attempt int) time.Duration {
+	d := p.BaseDelay << (attempt - 1)
+	if d <= 0 || (p.MaxDelay > 0 && d > p.MaxDelay) {
+		d = p.MaxDelay
+	}
+	if d <= 0 {
+		return 0
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
+
+func (s *Engin) isRetryable(err error) bool {
+	if s.retryPolicy.Retryable != nil {
+		return s.retryPolicy.Retryable(err)
+	}
+	return dialect.IsRetryable(s.driver, err)
+}
+
+// withRetry 按重试策略执行 fn, 未设置策略或不可重试的错误直接返回
+func (s *Engin) withRetry(fn func() error) (err error) {
+	for attempt := 1; ; attempt++ {
+		err = fn()
+		if err == nil || s.retryPolicy == nil || attempt >= s.retryPolicy.MaxAttempts || !s.isRetryable(err) {
+			return
+		}
+		select {
+		case <-s.context().Done():
+			return err
+		case <-time.After(s.retryPolicy.delay(attempt)):
+		}
+	}
+}
+
+// withRetryRead 查询语句的重试, 仅在开启 RetryReads 且不在事务中时重试
+func (s *Engin) withRetryRead(fn func() error) error {
+	if s.retryPolicy == nil || !s.retryPolicy.RetryReads || s.tx != nil {
+		return fn()
+	}
+	return s.withRetry(fn)
+}
+
+// resetBind 重试查询前清空上一次绑定到切片中的结果
+func resetBind(rfv reflect.Value, length int) {
+	if rfv.Kind() == reflect.Slice && rfv.CanSet() {
+		rfv.SetLen(length)
+	}
+}
@@ -0,0 +1,80 @@
+package gorose
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestEngin_TransactionRetry(t *testing.T) {
+	g, fake := newFake(t)
+	g.UseRetry(RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond})
+	var runs int
+	var transfer = func(e *Engin) error {
+		runs++
+		_, err := e.Exec("UPDATE accounts SET balance = balance - 1 WHERE id = 1")
+		return err
+	}
+
+	fake.failures.Store(2)
+	if err := g.NewEngin().Transaction(transfer); err != nil {
+		t.Fatal(err)
+	}
+	if runs != 3 {
+		t.Errorf("expect closure to run 3 times, got %d", runs)
+	}
+
+	runs = 0
+	fake.failures.Store(3)
+	var deadlock *fakeDeadlock
+	if err := g.NewEngin().Transaction(transfer); !errors.As(err, &deadlock) {
+		t.Errorf("expect deadlock error after max attempts, got %v", err)
+	}
+	if runs != 3 {
+		t.Errorf("expect closure to run 3 times, got %d", runs)
+	}
+	fake.failures.Store(0)
+
+	runs = 0
+	var errBusiness = errors.New("insufficient balance")
+	err := g.NewDatabase().Transaction(func(tx TxHandler) error {
+		runs++
+		return errBusiness
+	})
+	if !errors.Is(err, errBusiness) || runs != 1 {
+		t.Errorf("non-retryable errors should not be retried: %v, %d runs", err, runs)
+	}
+}
+
+func TestEngin_RetryableJoinedError(t *testing.T) {
+	g, _ := newFake(t)
+	var e = g.UseRetry(RetryPolicy{MaxAttempts: 2}).NewEngin()
+	var err = errors.Join(&fakeDeadlock{Number: 1213}, errors.New("rollback failed"))
+	if !e.isRetryable(err) {
+		t.Error("expect deadlock joined with rollback error to be retryable")
+	}
+	if !e.isRetryable(fmt.Errorf("exec: %w", err)) {
+		t.Error("expect wrapped joined deadlock to be retryable")
+	}
+	if e.isRetryable(errors.Join(errors.New("insufficient balance"), errors.New("rollback failed"))) {
+		t.Error("expect joined business errors not to be retryable")
+	}
+}
+
+func TestEngin_RetryReads(t *testing.T) {
+	g, fake := newFake(t)
+	g.UseRetry(RetryPolicy{MaxAttempts: 2, RetryReads: true})
+	var posts []benchPost
+	fake.failures.Store(1)
+	if err := g.NewEngin().QueryTo(&posts, "SELECT * FROM posts"); err != nil {
+		t.Fatal(err)
+	}
+	if len(posts) != fake.rows {
+		t.Errorf("expect %d rows, got %d", fake.rows, len(posts))
+	}
+	fake.failures.Store(1)
+	if count, err := g.NewDatabase().Table("posts").Count(); err != nil || count != 1 {
+		t.Errorf("unexpected count result: %d, %v", count, err)
+	}
+}
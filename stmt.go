@@ -0,0 +1,156 @@
+package gorose
+
+import (
+	"container/list"
+	"context"
+	"database/sql"
+	"sync"
+)
+
+// StmtCacheStats 预处理语句缓存统计
+type StmtCacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+}
+
+// HitRate 命中率
+func (s StmtCacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// stmtCache 预处理语句 LRU 缓存, 按 *sql.DB 分别缓存, 以 sql 文本作为 key
+type stmtCache struct {
+	size  int
+	mu    sync.Mutex
+	pools map[*sql.DB]*stmtPool
+	stats StmtCacheStats
+}
+type stmtPool struct {
+	ll    *list.List // *stmtEntry, 最近使用的在前
+	items map[string]*list.Element
+}
+type stmtEntry struct {
+	query   string
+	stmt    *sql.Stmt
+	refs    int  // 正在使用中的数量, 被淘汰时等到归还后再关闭
+	evicted bool // 已被淘汰
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{size: size, pools: map[*sql.DB]*stmtPool{}}
+}
+
+// acquire 获取预处理语句, 不存在时 prepare 并加入缓存, 使用完毕后需要调用 release 归还
+func (c *stmtCache) acquire(ctx context.Context, db *sql.DB, query string) (entry *stmtEntry, err error) {
+	c.mu.Lock()
+	pool, ok := c.pools[db]
+	if !ok {
+		pool = &stmtPool{ll: list.New(), items: map[string]*list.Element{}}
+		c.pools[db] = pool
+	}
+	if el, ok := pool.items[query]; ok {
+		pool.ll.MoveToFront(el)
+		entry = el.Value.(*stmtEntry)
+		entry.refs++
+		c.stats.Hits++
+		c.mu.Unlock()
+		return
+	}
+	c.stats.Misses++
+	c.mu.Unlock()
+
+	// prepare 需要访问数据库, 不持有锁
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if el, ok := pool.items[query]; ok { // 并发 prepare 了同一条语句
+		_ = stmt.Close()
+		pool.ll.MoveToFront(el)
+		entry = el.Value.(*stmtEntry)
+		entry.refs++
+		return
+	}
+	entry = &stmtEntry{query: query, stmt: stmt, refs: 1}
+	pool.items[query] = pool.ll.PushFront(entry)
+	for pool.ll.Len() > c.size {
+		c.evict(pool, pool.ll.Back())
+	}
+	return
+}
+
+// release 归还预处理语句, 已被淘汰且无人使用时关闭
+func (c *stmtCache) release(entry *stmtEntry) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry.refs--
+	if entry.evicted && entry.refs == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+func (c *stmtCache) evict(pool *stmtPool, el *list.Element) {
+	entry := el.Value.(*stmtEntry)
+	pool.ll.Remove(el)
+	delete(pool.items, entry.query)
+	entry.evicted = true
+	c.stats.Evictions++
+	if entry.refs == 0 {
+		_ = entry.stmt.Close()
+	}
+}
+
+// close 关闭所有缓存的预处理语句
+func (c *stmtCache) close() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for db, pool := range c.pools {
+		for pool.ll.Len() > 0 {
+			c.evict(pool, pool.ll.Back())
+		}
+		delete(c.pools, db)
+	}
+}
+
+func (c *stmtCache) Stats() StmtCacheStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.stats
+}
+
+// prepare 开启了预处理语句缓存时, 获取 sql 对应的预处理语句, 事务中通过 tx.Stmt 转换为事务语句;
+// 未开启时返回 nil
+func (s *Engin) prepare(ctx context.Context, db *sql.DB, query string) (stmt *sql.Stmt, release func(), err error) {
+	release = func() {}
+	if s.stmtCache == nil {
+		return
+	}
+	if s.tx != nil {
+		if stmt = s.txStmts[query]; stmt != nil {
+			return
+		}
+		db = s.txDB
+	}
+	entry, err := s.stmtCache.acquire(ctx, db, query)
+	if err != nil {
+		return
+	}
+	if s.tx == nil {
+		return entry.stmt, func() { s.stmtCache.release(entry) }, nil
+	}
+	// 事务语句在事务结束时自动关闭
+	stmt = s.tx.StmtContext(ctx, entry.stmt)
+	s.stmtCache.release(entry)
+	if s.txStmts == nil {
+		s.txStmts = map[string]*sql.Stmt{}
+	}
+	s.txStmts[query] = stmt
+	return
+}
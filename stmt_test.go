@@ -0,0 +1,25 @@
+package gorose
+
+import (
+	"testing"
+)
+
+func TestGoRose_StmtCache(t *testing.T) {
+	g, fake := newFake(t)
+	g.StmtCache(2)
+	for _, query := range []string{"UPDATE a SET x = 1", "UPDATE a SET x = 1", "UPDATE b SET x = 1", "UPDATE c SET x = 1", "UPDATE a SET x = 1"} {
+		if _, err := g.NewEngin().Exec(query); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stats := g.StmtCacheStats()
+	if stats.Hits != 1 || stats.Misses != 4 || stats.Evictions != 2 {
+		t.Errorf("unexpected stats: %+v", stats)
+	}
+	if got := fake.prepared.Load(); got != 4 {
+		t.Errorf("expect 4 prepares, got %d", got)
+	}
+	if rate := stats.HitRate(); rate != 0.2 {
+		t.Errorf("expect hit rate 0.2, got %v", rate)
+	}
+}
@@ -1,6 +1,8 @@
 package gorose
 
 import (
+	"errors"
+	"fmt"
 	"github.com/gohouse/gorose/v3/builder"
 	"math"
 )
@@ -16,7 +18,9 @@ type Pagination struct {
 	Data        []map[string]any `json:"data"`
 }
 
+// Paginate 分页查询, 不修改调用方的查询条件; 未指定 Limit 时每页15条, 未指定 Page 时为第1页
 func (db *Database) Paginate(obj ...any) (result Pagination, err error) {
+	db = db.fork()
 	if len(obj) > 0 {
 		db.Table(obj[0])
 	}
@@ -54,61 +58,230 @@ func (db *Database) Paginate(obj ...any) (result Pagination, err error) {
 }
 
 func (db *Database) WhereSub(column string, operation string, sub builder.WhereSubHandler) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereSub(column, operation, sub)
 	return db
 }
 func (db *Database) OrWhereSub(column string, operation string, sub builder.WhereSubHandler) *Database {
+	db = db.builder()
 	db.Context.WhereClause.OrWhereSub(column, operation, sub)
 	return db
 }
 func (db *Database) WhereBuilder(column string, operation string, sub builder.IBuilder) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereBuilder(column, operation, sub)
 	return db
 }
 func (db *Database) OrWhereBuilder(column string, operation string, sub builder.IBuilder) *Database {
+	db = db.builder()
 	db.Context.WhereClause.OrWhereBuilder(column, operation, sub)
 	return db
 }
 func (db *Database) WhereNested(handler builder.WhereNestedHandler) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereNested(handler)
 	return db
 }
 func (db *Database) OrWhereNested(handler builder.WhereNestedHandler) *Database {
+	db = db.builder()
 	db.Context.WhereClause.OrWhereNested(handler)
 	return db
 }
 func (db *Database) WhereIn(column string, value any) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereIn(column, value)
 	return db
 }
 func (db *Database) OrWhereIn(column string, value any) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereIn(column, value)
 	return db
 }
 func (db *Database) WhereNull(column string) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereNull(column)
 	return db
 }
 func (db *Database) OrWhereNull(column string) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereNull(column)
 	return db
 }
 func (db *Database) WhereBetween(column string, value any) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereBetween(column, value)
 	return db
 }
 func (db *Database) OrWhereBetween(column string, value any) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereBetween(column, value)
 	return db
 }
-func (db *Database) WhereExists(clause builder.IBuilder) {
+func (db *Database) WhereExists(clause builder.IBuilder) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereExists(clause)
+	return db
+}
+
+// WhereColumn 比较两列的值, 参考 builder.WhereClause.WhereColumn
+func (db *Database) WhereColumn(column1 string, operatorOrColumn2 ...string) *Database {
+	db = db.builder()
+	db.Context.WhereClause.WhereColumn(column1, operatorOrColumn2...)
+	return db
+}
+func (db *Database) OrWhereColumn(column1 string, operatorOrColumn2 ...string) *Database {
+	db = db.builder()
+	db.Context.WhereClause.OrWhereColumn(column1, operatorOrColumn2...)
+	return db
+}
+
+// WhereDate 比较列的日期部分, 参考 builder.WhereClause.WhereDate
+func (db *Database) WhereDate(column string, args ...any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.WhereDate(column, args...)
+	return db
+}
+func (db *Database) OrWhereDate(column string, args ...any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.OrWhereDate(column, args...)
+	return db
+}
+func (db *Database) WhereTime(column string, args ...any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.WhereTime(column, args...)
+	return db
+}
+func (db *Database) OrWhereTime(column string, args ...any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.OrWhereTime(column, args...)
+	return db
+}
+func (db *Database) WhereYear(column string, args ...any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.WhereYear(column, args...)
+	return db
+}
+func (db *Database) OrWhereYear(column string, args ...any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.OrWhereYear(column, args...)
+	return db
+}
+func (db *Database) WhereMonth(column string, args ...any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.WhereMonth(column, args...)
+	return db
+}
+func (db *Database) OrWhereMonth(column string, args ...any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.OrWhereMonth(column, args...)
+	return db
+}
+func (db *Database) WhereDay(column string, args ...any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.WhereDay(column, args...)
+	return db
+}
+func (db *Database) OrWhereDay(column string, args ...any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.OrWhereDay(column, args...)
+	return db
+}
+
+// WhereJsonContains json 数组包含某个值, 参考 builder.WhereClause.WhereJsonContains
+func (db *Database) WhereJsonContains(column string, value any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.WhereJsonContains(column, value)
+	return db
+}
+func (db *Database) OrWhereJsonContains(column string, value any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.OrWhereJsonContains(column, value)
+	return db
+}
+func (db *Database) WhereJsonDoesntContain(column string, value any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.WhereJsonDoesntContain(column, value)
+	return db
+}
+func (db *Database) OrWhereJsonDoesntContain(column string, value any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.OrWhereJsonDoesntContain(column, value)
+	return db
+}
+
+// WhereJsonLength 比较 json 数组的长度, 参考 builder.WhereClause.WhereJsonLength
+func (db *Database) WhereJsonLength(column string, args ...any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.WhereJsonLength(column, args...)
+	return db
+}
+func (db *Database) OrWhereJsonLength(column string, args ...any) *Database {
+	db = db.builder()
+	db.Context.WhereClause.OrWhereJsonLength(column, args...)
+	return db
+}
+
+// WhereFullText 全文检索, 参考 builder.WhereClause.WhereFullText
+func (db *Database) WhereFullText(columns []string, query string, options ...builder.FullTextOptions) *Database {
+	db = db.builder()
+	db.Context.WhereClause.WhereFullText(columns, query, options...)
+	return db
+}
+func (db *Database) OrWhereFullText(columns []string, query string, options ...builder.FullTextOptions) *Database {
+	db = db.builder()
+	db.Context.WhereClause.OrWhereFullText(columns, query, options...)
+	return db
+}
+
+// SelectFullTextScore 查询全文检索的相关度, 如:
+//
+//	WhereFullText(cols, "golang").SelectFullTextScore(cols, "golang", "score").OrderByFullTextScore(cols, "golang")
+//
+// mssql, oracle 不支持, 生成 sql 时返回错误; sqlite3 为 fts5 的 rank, 即 WhereFullText 条件的相关度, columns 和 query 不参与计算
+func (db *Database) SelectFullTextScore(columns []string, query, alias string, options ...builder.FullTextOptions) *Database {
+	db = db.builder()
+	score, binds, err := db.fullTextScore(columns, query, options...)
+	if err != nil {
+		db.Context.SelectClause.Err = err
+		return db
+	}
+	db.Context.SelectClause.SelectRaw(fmt.Sprintf("%s AS %s", score, db.Driver.Dialect.QuoteIdentifier(alias)), binds...)
+	return db
+}
+
+// OrderByFullTextScore 按全文检索的相关度从高到低排序, 参考 SelectFullTextScore
+func (db *Database) OrderByFullTextScore(columns []string, query string, options ...builder.FullTextOptions) *Database {
+	db = db.builder()
+	score, binds, err := db.fullTextScore(columns, query, options...)
+	if err != nil {
+		db.Context.OrderByClause.Err = err
+		return db
+	}
+	db.Context.OrderByClause.OrderBy(builder.Expr{Sql: score, Binds: binds}, "DESC")
+	return db
+}
+func (db *Database) fullTextScore(columns []string, query string, options ...builder.FullTextOptions) (score string, binds []any, err error) {
+	var option builder.FullTextOptions
+	if len(options) > 0 {
+		option = options[0]
+	}
+	var cols []string
+	for _, column := range columns {
+		cols = append(cols, db.Driver.Dialect.QuoteIdentifier(column))
+	}
+	score, binds = db.Driver.Dialect.FullTextScore(cols, query, option.Mode, option.Language)
+	if score == "" {
+		err = errors.New("full text score not supported by the dialect")
+	}
+	return
 }
 func (db *Database) WhereLike(column, value string) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereLike(column, value)
 	return db
 }
 func (db *Database) OrWhereLike(column, value string) *Database {
+	db = db.builder()
 	db.Context.WhereClause.OrWhereLike(column, value)
 	return db
 }
@@ -150,6 +323,7 @@ func (db *Database) OrWhereLike(column, value string) *Database {
 //}
 
 func (db *Database) WhereNot(column any, args ...any) *Database {
+	db = db.builder()
 	db.Context.WhereClause.WhereNot(column, args...)
 	return db
 }
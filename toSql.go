@@ -4,27 +4,32 @@ import (
 	"errors"
 	"fmt"
 	"github.com/gohouse/gorose/v3/builder"
+	"github.com/gohouse/gorose/v3/driver"
 	"github.com/gohouse/gorose/v3/parser"
 	"reflect"
 )
 
 func (db *Database) ToSqlSelect() (sql4prepare string, binds []any) {
-	return db.Driver.ToSqlSelect(db.Context)
+	sql4prepare, binds = db.Driver.ToSqlSelect(db.Context)
+	return db.Driver.Renumber(sql4prepare), binds
 }
 
 func (db *Database) ToSqlTable() (sql4prepare string, values []any, err error) {
-	return db.Driver.ToSqlTable(db.Context)
+	sql4prepare, values, err = db.Driver.ToSqlTable(db.Context)
+	return db.Driver.Renumber(sql4prepare), values, err
 }
 func (db *Database) ToSqlJoin() (sql4prepare string, binds []any, err error) {
-	return db.Driver.ToSqlJoin(db.Context)
+	sql4prepare, binds, err = db.Driver.ToSqlJoin(db.Context)
+	return db.Driver.Renumber(sql4prepare), binds, err
 }
 
 func (db *Database) ToSqlWhere() (sql4prepare string, values []any, err error) {
-	return db.Driver.ToSqlWhere(db.Context)
+	sql4prepare, values, err = db.Driver.ToSqlWhere(db.Context)
+	return db.Driver.Renumber(sql4prepare), values, err
 }
 
 func (db *Database) ToSqlOrderBy() (sql4prepare string) {
-	return db.Driver.ToSqlOrderBy(db.Context)
+	return db.Driver.Renumber(db.Driver.ToSqlOrderBy(db.Context))
 }
 
 func (db *Database) ToSqlLimitOffset() (sqlSegment string, binds []any) {
@@ -35,6 +40,19 @@ func (db *Database) ToSql() (sql4prepare string, values []any, err error) {
 	return db.Driver.ToSql(db.Context)
 }
 
+// ToRawSql 同 ToSql, 但将绑定值按方言替换到占位符中, 如:
+//
+//	SELECT * FROM `users` WHERE `name` = 'O\'Brien' AND `id` > 10
+//
+// 仅用于调试, 如复制到数据库客户端中执行; 不能用于拼接执行的 sql
+func (db *Database) ToRawSql() (rawSql string, err error) {
+	prepare, values, err := db.ToSql()
+	if err != nil {
+		return
+	}
+	return driver.Interpolate(db.Driver.Dialect, prepare, values), nil
+}
+
 func (db *Database) ToSqlExists(bind ...any) (sql4prepare string, values []any, err error) {
 	if len(bind) > 0 {
 		sql4prepare, values, err = db.ToSqlTo(bind[0])
@@ -49,14 +67,14 @@ func (db *Database) ToSqlExists(bind ...any) (sql4prepare string, values []any,
 }
 
 func (db *Database) ToSqlAggregate(function, column string) (sql4prepare string, values []any, err error) {
-	var ctx = *db.Context
+	var ctx = db.Context.Clone()
 	ctx.SelectClause.Columns = append(ctx.SelectClause.Columns, builder.Column{
 		Name:  fmt.Sprintf("%s(%s)", function, column),
 		Alias: function,
 		IsRaw: true,
 		Binds: []any{},
 	})
-	return db.Driver.ToSql(&ctx)
+	return db.Driver.ToSql(ctx)
 }
 
 func (db *Database) ToSqlTo(obj any, mustColumn ...string) (sql4prepare string, binds []any, err error) {
@@ -69,10 +87,10 @@ func (db *Database) ToSqlTo(obj any, mustColumn ...string) (sql4prepare string,
 		if err != nil {
 			return
 		}
-		sql4prepare, binds, err = db.Table(obj).Select(columns...).Where(data).Limit(1).ToSql()
+		sql4prepare, binds, err = db.fork().Table(obj).Select(columns...).Where(data).Limit(1).ToSql()
 	case reflect.Slice:
 		if rfv.Type().Elem().Kind() == reflect.Struct {
-			sql4prepare, binds, err = db.Table(obj).Select(columns...).ToSql()
+			sql4prepare, binds, err = db.fork().Table(obj).Select(columns...).ToSql()
 		}
 	default:
 		err = errors.New("obj must be struct(slice) or map(slice)")
@@ -91,6 +109,11 @@ func (db *Database) ToSqlUpdate(obj any, mustColumn ...string) (sqlSegment strin
 	return db.Driver.ToSqlUpdate(db.Context, builder.TypeToSqlUpdateCase{BindOrData: obj, MustColumn: mustColumn})
 }
 
+// ToSqlUpdateBatch 批量更新的语句, 每批一条, 参考 Database.UpdateBatch
+func (db *Database) ToSqlUpdateBatch(rows any, keyColumn string, mustColumn ...string) (sqls []string, binds [][]any, err error) {
+	return db.Driver.ToSqlUpdateBatch(db.Context, rows, keyColumn, mustColumn...)
+}
+
 // ToSqlIncDec
 //
 //	symbol: +/-
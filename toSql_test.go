@@ -1,8 +1,16 @@
 package gorose
 
 import (
+	"database/sql"
+	"errors"
+	"github.com/gohouse/gorose/v3/builder"
 	"github.com/gohouse/gorose/v3/driver"
+	"github.com/gohouse/gorose/v3/driver/dialect"
+	"github.com/gohouse/gorose/v3/parser"
+	"reflect"
+	"strings"
 	"testing"
+	"time"
 )
 
 type User struct {
@@ -92,3 +100,709 @@ func TestDatabase_ToSqlDelete(t *testing.T) {
 	var expectValues = []any{1, ""}
 	driver.AssertsEqual(t, expectValues, values)
 }
+func TestDatabase_ToSqlTenant(t *testing.T) {
+	var tdb = func() *Database {
+		return Open(dbg.driver).TenantMode("tenant_id").NewDatabase().Tenant(3)
+	}
+	prepare, values, err := tdb().Table("users").Where("id", 1).OrWhere("id", 2).ToSql()
+	driver.AssertsError(t, err)
+	var expect = map[string]string{
+		"mysql":      "SELECT * FROM `users` WHERE (`id` = ? OR `id` = ?) AND `users`.`tenant_id` = ?",
+		"postgresql": `SELECT * FROM "users" WHERE ("id" = $1 OR "id" = $2) AND "users"."tenant_id" = $3`,
+	}
+	driver.AssertsEqual(t, expect[dbg.driver], prepare)
+	driver.AssertsEqual(t, []any{1, 2, 3}, values)
+
+	prepare, values, err = tdb().Table("users").ToSqlInsert(map[string]any{"name": "john"})
+	driver.AssertsError(t, err)
+	expect = map[string]string{
+		"mysql":      "INSERT INTO `users` (`name`,`tenant_id`) VALUES (?,?) ",
+		"postgresql": `INSERT INTO "users" ("name","tenant_id") VALUES ($1,$2) `,
+	}
+	driver.AssertsEqual(t, expect[dbg.driver], prepare)
+	driver.AssertsEqual(t, []any{"john", 3}, values)
+
+	_, _, err = Open(dbg.driver).TenantMode("tenant_id").NewDatabase().Table("users").ToSql()
+	if err == nil {
+		t.Error("expect error without tenant value")
+	}
+	_, err = tdb().Exec("DELETE FROM users")
+	if !errors.Is(err, ErrTenantUnsafe) {
+		t.Errorf("expect ErrTenantUnsafe, got %v", err)
+	}
+	var count int64
+	err = tdb().QueryRow("SELECT COUNT(*) FROM users").Scan(&count)
+	if !errors.Is(err, ErrTenantUnsafe) {
+		t.Errorf("expect ErrTenantUnsafe, got %v", err)
+	}
+
+	// TenantSafe 只对下一条语句有效
+	g, _ := newFake(t)
+	var engin = g.TenantMode("tenant_id").NewEngin()
+	if _, err = engin.TenantSafe().Exec("DELETE FROM users WHERE tenant_id = ?", 3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err = engin.Exec("DELETE FROM users"); !errors.Is(err, ErrTenantUnsafe) {
+		t.Errorf("expect ErrTenantUnsafe after the marked statement, got %v", err)
+	}
+}
+func TestDatabase_ToSqlUpdateVersion(t *testing.T) {
+	type Article struct {
+		Id      int64  `db:"id,pk"`
+		Title   string `db:"title"`
+		Version int64  `db:"version,version"`
+	}
+	var article = Article{Id: 1, Title: "gorose", Version: 3}
+	prepare, values, err := db().ToSqlUpdate(&article)
+	driver.AssertsError(t, err)
+	var expect = map[string]string{
+		"mysql":      "UPDATE `Article` SET `title` = ?, `version` = `version` + 1 WHERE `id` = ? AND `version` = ?",
+		"postgresql": `UPDATE "Article" SET "title" = $1, "version" = "version" + 1 WHERE "id" = $2 AND "version" = $3`,
+	}
+	driver.AssertsEqual(t, expect[dbg.driver], prepare)
+	driver.AssertsEqual(t, []any{"gorose", 1, 3}, values)
+}
+func TestDatabase_ToSqlEmbedded(t *testing.T) {
+	type BaseModel struct {
+		Id        int64  `db:"id,pk"`
+		CreatedAt string `db:"created_at"`
+	}
+	type Audit struct {
+		By string `db:"by"`
+	}
+	type Post struct {
+		BaseModel
+		*Audit `db:"audit_"`
+		Title  string `db:"title"`
+		Author User   `db:"author"`
+	}
+	var post = Post{BaseModel: BaseModel{Id: 1, CreatedAt: "2024-01-01"}, Audit: &Audit{By: "john"}, Title: "gorose"}
+	prepare, values, err := db().ToSqlUpdate(&post)
+	driver.AssertsError(t, err)
+	var expect = map[string]string{
+		"mysql":      "UPDATE `Post` SET `audit_by` = ?, `created_at` = ?, `title` = ? WHERE `id` = ?",
+		"postgresql": `UPDATE "Post" SET "audit_by" = $1, "created_at" = $2, "title" = $3 WHERE "id" = $4`,
+	}
+	driver.AssertsEqual(t, expect[dbg.driver], prepare)
+	driver.AssertsEqual(t, []any{"john", "2024-01-01", "gorose", 1}, values)
+
+	fieldTag, fieldStruct := parser.StructsScanParse(reflect.TypeOf(post))
+	driver.AssertsEqual(t, []string{"id", "created_at", "audit_by", "title", "author.id", "author.name"}, fieldTag)
+	driver.AssertsEqual(t, []string{"BaseModel.Id", "BaseModel.CreatedAt", "Audit.By", "Title", "Author.Id", "Author.Name"}, fieldStruct)
+}
+func TestDatabase_ToSqlSelfReference(t *testing.T) {
+	type Category struct {
+		Id       int64     `db:"id,pk"`
+		Name     string    `db:"name"`
+		Parent   *Category `db:"parent"`
+		Children []Category
+	}
+	fieldTag, fieldStruct := parser.StructsScanParse(reflect.TypeOf(Category{}))
+	driver.AssertsEqual(t, []string{"id", "name"}, fieldTag)
+	driver.AssertsEqual(t, []string{"Id", "Name"}, fieldStruct)
+
+	// 关联字段不参与写入
+	prepare, values, err := Open("mysql").NewDatabase().ToSqlInsert(&Category{Id: 2, Name: "go", Parent: &Category{Id: 1}})
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, "INSERT INTO `Category` (`id`,`name`) VALUES (?,?)", strings.TrimSpace(prepare))
+	driver.AssertsEqual(t, []any{int64(2), "go"}, values)
+}
+
+func TestDatabase_ToRawSql(t *testing.T) {
+	var created = time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	raw, err := db().Table("users").Where("name", "O'Brien").Where("created_at", ">", created).Where("age", ">", 18).ToRawSql()
+	driver.AssertsError(t, err)
+	var expect = map[string]string{
+		"mysql":      "SELECT * FROM `users` WHERE `name` = 'O\\'Brien' AND `created_at` > '2024-01-02 03:04:05' AND `age` > 18",
+		"postgresql": `SELECT * FROM "users" WHERE "name" = 'O''Brien' AND "created_at" > '2024-01-02 03:04:05Z' AND "age" > 18`,
+		"mssql":      `SELECT * FROM [users] WHERE [name] = N'O''Brien' AND [created_at] > '2024-01-02T03:04:05' AND [age] > 18`,
+		"oracle":     `SELECT * FROM "users" WHERE "name" = 'O''Brien' AND "created_at" > TIMESTAMP '2024-01-02 03:04:05' AND "age" > 18`,
+		"sqlite3":    `SELECT * FROM "users" WHERE "name" = 'O''Brien' AND "created_at" > '2024-01-02 03:04:05+00:00' AND "age" > 18`,
+	}
+	driver.AssertsEqual(t, expect[dbg.driver], raw)
+
+	var item = SqlItem{Sql: "UPDATE users SET name = $1, note = '$2?' WHERE id = $2 AND deleted = $3", Bindings: []any{nil, 3, true}, dialect: &dialect.PostgresqlDialect{}}
+	driver.AssertsEqual(t, "UPDATE users SET name = NULL, note = '$2?' WHERE id = 3 AND deleted = TRUE", item.String())
+	item = SqlItem{Sql: "INSERT INTO t (a,b,c) VALUES (?,?,?)", Bindings: []any{[]byte{0xca, 0xfe}, "a\\b", sql.NullString{}}, dialect: &dialect.MySQLDialect{}}
+	driver.AssertsEqual(t, `INSERT INTO t (a,b,c) VALUES (X'cafe','a\\b',NULL)`, item.String())
+}
+
+func TestDatabase_ToSqlPlaceholder(t *testing.T) {
+	var sub = db().Table("orders").Select("user_id").Where("amount", ">", 100)
+	var b = db().Table(db().Table("users").Where("status", 1), "u").
+		Where("age", ">", 18).
+		Where("id", "in", sub).
+		Union(db().Table("admins").Where("level", 9))
+	prepare, values, err := b.ToSql()
+	driver.AssertsError(t, err)
+	var expect = map[string]string{
+		"mysql":      "SELECT * FROM (SELECT * FROM `users` WHERE `status` = ?) `u` WHERE `age` > ? AND `id` in (SELECT `user_id` FROM `orders` WHERE `amount` > ?) UNION SELECT * FROM `admins` WHERE `level` = ?",
+		"postgresql": `SELECT * FROM (SELECT * FROM "users" WHERE "status" = $1) "u" WHERE "age" > $2 AND "id" in (SELECT "user_id" FROM "orders" WHERE "amount" > $3) UNION SELECT * FROM "admins" WHERE "level" = $4`,
+		"mssql":      `SELECT * FROM (SELECT * FROM [users] WHERE [status] = @p1) [u] WHERE [age] > @p2 AND [id] in (SELECT [user_id] FROM [orders] WHERE [amount] > @p3) UNION SELECT * FROM [admins] WHERE [level] = @p4`,
+		"oracle":     `SELECT * FROM (SELECT * FROM "users" WHERE "status" = @p1) "u" WHERE "age" > @p2 AND "id" in (SELECT "user_id" FROM "orders" WHERE "amount" > @p3) UNION SELECT * FROM "admins" WHERE "level" = @p4`,
+		"sqlite3":    `SELECT * FROM (SELECT * FROM "users" WHERE "status" = ?) "u" WHERE "age" > ? AND "id" in (SELECT "user_id" FROM "orders" WHERE "amount" > ?) UNION SELECT * FROM "admins" WHERE "level" = ?`,
+	}
+	driver.AssertsEqual(t, expect[dbg.driver], prepare)
+	driver.AssertsEqual(t, []any{1, 18, 100, 9}, values)
+
+	// 重复生成结果不变
+	prepare2, _, err := b.ToSql()
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, prepare, prepare2)
+}
+
+type reusedPlaceholderSub struct {
+	*Database
+}
+
+func (reusedPlaceholderSub) ToSql() (string, []any, error) {
+	return `SELECT "uid" FROM "card" WHERE "a" = $2 OR "b" = $1 OR "c" = $2`, []any{"x", "y"}, nil
+}
+
+func TestDatabase_ToSqlPlaceholderLiteral(t *testing.T) {
+	var pg = func() *Database { return Open("postgresql").NewDatabase() }
+	prepare, values, err := pg().Table("users").
+		WhereRaw("meta ? 'vip'").
+		WhereRaw("meta ?| ? AND tags ?& ?", []any{"{a,b}", "{c}"}).
+		WhereRaw("meta ?? ? AND age > ?", []any{"k", 18}).
+		Where("meta", "?", Raw("?", "vip")).
+		Where("id", "in", reusedPlaceholderSub{pg()}).
+		ToSql()
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, `SELECT * FROM "users" WHERE meta ? 'vip' AND meta ?| $1 AND tags ?& $2 AND meta ? $3 AND age > $4 AND "meta" ? $5 AND "id" in (SELECT "uid" FROM "card" WHERE "a" = $6 OR "b" = $7 OR "c" = $8)`, prepare)
+	driver.AssertsEqual(t, []any{"{a,b}", "{c}", "k", 18, "vip", "y", "x", "y"}, values)
+
+	// 嵌套的子查询中的字面量 ? 不被编号
+	prepare, values, err = pg().Table("users").Where("id", "in", pg().Table("card").Select("uid").WhereRaw("meta ? 'k'").Where("a", 1)).ToSql()
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, `SELECT * FROM "users" WHERE "id" in (SELECT "uid" FROM "card" WHERE meta ? 'k' AND "a" = $1)`, prepare)
+	driver.AssertsEqual(t, []any{1}, values)
+}
+
+func TestDatabase_Clone(t *testing.T) {
+	var base = db().Table("users").Where("active", 1)
+	var page = base.Clone().Where("age", ">", 18).Limit(10)
+	prepare, values, err := base.ToSql()
+	driver.AssertsError(t, err)
+	var expect = map[string]string{
+		"mysql":      "SELECT * FROM `users` WHERE `active` = ?",
+		"postgresql": `SELECT * FROM "users" WHERE "active" = $1`,
+	}
+	if sql, ok := expect[dbg.driver]; ok {
+		driver.AssertsEqual(t, sql, prepare)
+	}
+	driver.AssertsEqual(t, []any{1}, values)
+	_, values, err = page.ToSql()
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, []any{1, 18}, values)
+
+	// 不可变模式
+	var immutable = db().Immutable().Table("users").Where("active", 1)
+	_, values, _ = immutable.Where("role", "admin").ToSql()
+	driver.AssertsEqual(t, []any{1, "admin"}, values)
+	prepare2, values, _ := immutable.ToSql()
+	driver.AssertsEqual(t, prepare, prepare2)
+	driver.AssertsEqual(t, []any{1}, values)
+	_, _, _ = immutable.JoinOn("card", func(on builder.IJoinOn) { on.On("card.uid", "users.id") }).ToSql()
+	_, _, _ = immutable.WhereExists(db().Table("card").Where("card.uid", 1)).ToSql()
+	prepare2, values, _ = immutable.ToSql()
+	driver.AssertsEqual(t, prepare, prepare2)
+	driver.AssertsEqual(t, []any{1}, values)
+
+	// 执行方法不修改调用方
+	g, _ := newFake(t)
+	var fake = g.NewDatabase().Table("posts").Where("id", ">", 1)
+	before, _, _ := fake.ToSql()
+	if _, err = fake.First(); err != nil {
+		t.Fatal(err)
+	}
+	after, _, _ := fake.ToSql()
+	driver.AssertsEqual(t, before, after)
+
+	prepare, values, _ = fake.Reset().Table("users").ToSql()
+	driver.AssertsEqual(t, "SELECT * FROM `users`", prepare)
+	driver.AssertsEqual(t, 0, len(values))
+}
+
+func TestDatabase_ToSqlExpr(t *testing.T) {
+	prepare, values, err := db().Table("users").
+		Join("card", "uid", "=", Raw("users.id + ?", 1)).
+		Where("score", ">", Raw("COALESCE(bonus, ?)", 0)).
+		Where(Raw("age BETWEEN ? AND ?", 18, 30)).
+		GroupByExpr(Raw("DATE_FORMAT(created_at, ?)", "%Y")).
+		OrderBy(Raw("FIELD(status, ?)", "vip"), "DESC").
+		ToSql()
+	driver.AssertsError(t, err)
+	var expect = map[string]string{
+		"mysql":      "SELECT * FROM `users` INNER JOIN `card` ON `uid` = users.id + ? WHERE `score` > COALESCE(bonus, ?) AND age BETWEEN ? AND ? GROUP BY DATE_FORMAT(created_at, ?) ORDER BY FIELD(status, ?) DESC",
+		"postgresql": `SELECT * FROM "users" INNER JOIN "card" ON "uid" = users.id + $1 WHERE "score" > COALESCE(bonus, $2) AND age BETWEEN $3 AND $4 GROUP BY DATE_FORMAT(created_at, $5) ORDER BY FIELD(status, $6) DESC`,
+	}
+	if sql, ok := expect[dbg.driver]; ok {
+		driver.AssertsEqual(t, sql, prepare)
+	}
+	driver.AssertsEqual(t, []any{1, 0, 18, 30, "%Y", "vip"}, values)
+
+	prepare, values, err = db().Table("users").Where("id", 1).ToSqlUpdate(map[string]any{"updated_at": Raw("NOW()"), "score": Raw("score + ?", 5)})
+	driver.AssertsError(t, err)
+	expect = map[string]string{
+		"mysql":      "UPDATE `users` SET `score` = score + ?, `updated_at` = NOW() WHERE `id` = ?",
+		"postgresql": `UPDATE "users" SET "score" = score + $1, "updated_at" = NOW() WHERE "id" = $2`,
+	}
+	if sql, ok := expect[dbg.driver]; ok {
+		driver.AssertsEqual(t, sql, prepare)
+	}
+	driver.AssertsEqual(t, []any{5, 1}, values)
+
+	prepare, values, err = db().Table("users").ToSqlInsert(map[string]any{"name": "john", "created_at": Raw("NOW()")})
+	driver.AssertsError(t, err)
+	expect = map[string]string{
+		"mysql":      "INSERT INTO `users` (`created_at`,`name`) VALUES (NOW(),?)",
+		"postgresql": `INSERT INTO "users" ("created_at","name") VALUES (NOW(),$1)`,
+	}
+	if sql, ok := expect[dbg.driver]; ok {
+		driver.AssertsEqual(t, sql, strings.TrimSpace(prepare))
+	}
+	driver.AssertsEqual(t, []any{"john"}, values)
+}
+
+func TestDatabase_ToSqlWhereExprColumn(t *testing.T) {
+	prepare, values, err := db().Table("users").
+		Where(Raw("LOWER(name)"), "=", "x").
+		OrWhere(Raw("COALESCE(age, ?)", 0), "in", []int{18, 20}).
+		Where(Raw("YEAR(created_at)"), "between", []any{2020, Raw("YEAR(NOW())")}).
+		ToSql()
+	driver.AssertsError(t, err)
+	var expect = map[string]string{
+		"mysql":      "SELECT * FROM `users` WHERE LOWER(name) = ? OR COALESCE(age, ?) IN (?,?) AND YEAR(created_at) BETWEEN ? AND YEAR(NOW())",
+		"postgresql": `SELECT * FROM "users" WHERE LOWER(name) = $1 OR COALESCE(age, $2) IN ($3,$4) AND YEAR(created_at) BETWEEN $5 AND YEAR(NOW())`,
+	}
+	if sql, ok := expect[dbg.driver]; ok {
+		driver.AssertsEqual(t, sql, prepare)
+	}
+	driver.AssertsEqual(t, []any{"x", 0, 18, 20, 2020}, values)
+
+	_, _, err = db().Table("users").Where(Raw("LOWER(name)"), "in", db().Table("card").Select("name")).ToSql()
+	if err == nil {
+		t.Error("expect error for expression column with sub query")
+	}
+	_, _, err = db().Table("users").Where(Raw("LOWER(name)"), "between", 1).ToSql()
+	if err == nil {
+		t.Error("expect error for expression column between without range")
+	}
+}
+
+func TestDatabase_ToSqlWhereColumnDate(t *testing.T) {
+	var day = time.Date(2024, 3, 5, 10, 20, 30, 0, time.UTC)
+	var expect = map[string]string{
+		"mysql":      "SELECT * FROM `orders` WHERE `orders`.`updated_at` > `orders`.`created_at` OR `paid_at` = `created_at` AND DATE(`created_at`) >= ? AND TIME(`created_at`) = ? AND YEAR(`created_at`) = ? AND MONTH(`created_at`) = ? OR DAY(`created_at`) = ?",
+		"postgresql": `SELECT * FROM "orders" WHERE "orders"."updated_at" > "orders"."created_at" OR "paid_at" = "created_at" AND "created_at"::date >= $1 AND "created_at"::time = $2 AND EXTRACT(YEAR FROM "created_at") = $3 AND EXTRACT(MONTH FROM "created_at") = $4 OR EXTRACT(DAY FROM "created_at") = $5`,
+		"mssql":      `SELECT * FROM [orders] WHERE [orders].[updated_at] > [orders].[created_at] OR [paid_at] = [created_at] AND CAST([created_at] AS DATE) >= @p1 AND CAST([created_at] AS TIME) = @p2 AND DATEPART(year, [created_at]) = @p3 AND DATEPART(month, [created_at]) = @p4 OR DATEPART(day, [created_at]) = @p5`,
+		"oracle":     `SELECT * FROM "orders" WHERE "orders"."updated_at" > "orders"."created_at" OR "paid_at" = "created_at" AND TRUNC("created_at") >= @p1 AND TO_CHAR("created_at", 'HH24:MI:SS') = @p2 AND EXTRACT(YEAR FROM "created_at") = @p3 AND EXTRACT(MONTH FROM "created_at") = @p4 OR EXTRACT(DAY FROM "created_at") = @p5`,
+		"sqlite3":    `SELECT * FROM "orders" WHERE "orders"."updated_at" > "orders"."created_at" OR "paid_at" = "created_at" AND date("created_at") >= ? AND time("created_at") = ? AND CAST(strftime('%Y', "created_at") AS INTEGER) = ? AND CAST(strftime('%m', "created_at") AS INTEGER) = ? OR CAST(strftime('%d', "created_at") AS INTEGER) = ?`,
+	}
+	for name, sql := range expect {
+		prepare, values, err := Open(name).NewDatabase().Table("orders").
+			WhereColumn("orders.updated_at", ">", "orders.created_at").
+			OrWhereColumn("paid_at", "created_at").
+			WhereDate("created_at", ">=", day).
+			WhereTime("created_at", "10:20:30").
+			WhereYear("created_at", 2024).
+			WhereMonth("created_at", day).
+			OrWhereDay("created_at", 5).
+			ToSql()
+		driver.AssertsError(t, err)
+		driver.AssertsEqual(t, sql, prepare)
+		driver.AssertsEqual(t, []any{"2024-03-05", "10:20:30", 2024, 3, 5}, values)
+	}
+
+	_, _, err := Open("mysql").NewDatabase().Table("orders").WhereDate("created_at", 1, day).ToSql()
+	if err == nil {
+		t.Error("expect error for non-string date operator")
+	}
+}
+
+func TestDatabase_ToSqlJson(t *testing.T) {
+	var expect = map[string][2]string{
+		"mysql": {
+			"SELECT * FROM `users` WHERE JSON_UNQUOTE(JSON_EXTRACT(`meta`, '$.\"address\".\"city\"')) = ? AND JSON_CONTAINS(`meta`, ?, '$.\"tags\"') OR NOT JSON_CONTAINS(`meta`, ?, '$.\"tags\"') AND JSON_LENGTH(`meta`, '$.\"tags\"') > ?",
+			"UPDATE `users` SET `meta` = JSON_SET(JSON_SET(`meta`, '$.\"address\".\"city\"', ?), '$.\"tags\"', CAST(? AS JSON)), `name` = ? WHERE `id` = ?",
+		},
+		"postgresql": {
+			`SELECT * FROM "users" WHERE "meta"->'address'->>'city' = $1 AND ("meta"->'tags')::jsonb @> $2::jsonb OR NOT ("meta"->'tags')::jsonb @> $3::jsonb AND jsonb_array_length(("meta"->'tags')::jsonb) > $4`,
+			`UPDATE "users" SET "meta" = jsonb_set(jsonb_set("meta"::jsonb, '{address,city}', $1::jsonb)::jsonb, '{tags}', $2::jsonb), "name" = $3 WHERE "id" = $4`,
+		},
+		"mssql": {
+			`SELECT * FROM [users] WHERE JSON_VALUE([meta], '$."address"."city"') = @p1 AND @p2 IN (SELECT [value] FROM OPENJSON([meta], '$."tags"')) OR NOT @p3 IN (SELECT [value] FROM OPENJSON([meta], '$."tags"')) AND (SELECT COUNT(*) FROM OPENJSON([meta], '$."tags"')) > @p4`,
+			`UPDATE [users] SET [meta] = JSON_MODIFY(JSON_MODIFY([meta], '$."address"."city"', @p1), '$."tags"', JSON_QUERY(@p2)), [name] = @p3 WHERE [id] = @p4`,
+		},
+		"oracle": {
+			`SELECT * FROM "users" WHERE JSON_VALUE("meta", '$."address"."city"') = @p1 AND JSON_EXISTS("meta", '$."tags"[*]?(@ == $v)' PASSING @p2 AS "v") OR NOT JSON_EXISTS("meta", '$."tags"[*]?(@ == $v)' PASSING @p3 AS "v") AND JSON_VALUE("meta", '$."tags".size()') > @p4`,
+			`UPDATE "users" SET "meta" = JSON_TRANSFORM(JSON_TRANSFORM("meta", SET '$."address"."city"' = @p1), SET '$."tags"' = @p2 FORMAT JSON), "name" = @p3 WHERE "id" = @p4`,
+		},
+		"sqlite3": {
+			`SELECT * FROM "users" WHERE json_extract("meta", '$."address"."city"') = ? AND EXISTS (SELECT 1 FROM json_each("meta", '$."tags"') WHERE json_each.value = ?) OR NOT EXISTS (SELECT 1 FROM json_each("meta", '$."tags"') WHERE json_each.value = ?) AND json_array_length("meta", '$."tags"') > ?`,
+			`UPDATE "users" SET "meta" = json_set(json_set("meta", '$."address"."city"', ?), '$."tags"', json(?)), "name" = ? WHERE "id" = ?`,
+		},
+	}
+	for name, sql := range expect {
+		prepare, _, err := Open(name).NewDatabase().Table("users").
+			Where("meta->address->city", "Berlin").
+			WhereJsonContains("meta->tags", "go").
+			OrWhereJsonDoesntContain("meta->tags", "php").
+			WhereJsonLength("meta->tags", ">", 1).
+			ToSql()
+		driver.AssertsError(t, err)
+		driver.AssertsEqual(t, sql[0], prepare)
+
+		prepare, _, err = Open(name).NewDatabase().Table("users").Where("id", 1).
+			ToSqlUpdate(map[string]any{"meta->address->city": "Paris", "meta->tags": []string{"a"}, "name": "x"})
+		driver.AssertsError(t, err)
+		driver.AssertsEqual(t, sql[1], prepare)
+	}
+
+	// postgresql 路径中含 , { } " 的键加双引号转义
+	prepare, _, err := Open("postgresql").NewDatabase().Table("users").Where("id", 1).
+		ToSqlUpdate(map[string]any{`meta->a,b->{c}->d"e`: 1})
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, `UPDATE "users" SET "meta" = jsonb_set("meta"::jsonb, '{"a,b","{c}","d\"e"}', $1::jsonb) WHERE "id" = $2`, prepare)
+
+	// 无法序列化为 json 的值返回错误
+	_, _, err = Open("mysql").NewDatabase().Table("users").WhereJsonContains("meta->tags", make(chan int)).ToSql()
+	if err == nil {
+		t.Error("expect json marshal error for where json contains")
+	}
+	_, _, err = Open("mysql").NewDatabase().Table("users").Where("id", 1).
+		ToSqlUpdate(map[string]any{"meta->tags": []any{make(chan int)}})
+	if err == nil {
+		t.Error("expect json marshal error for json set")
+	}
+	_, _, err = Open("mysql").NewDatabase().Table("users").WhereJsonLength("meta->tags", 1, 2).ToSql()
+	if err == nil {
+		t.Error("expect error for non-string json length operator")
+	}
+}
+
+func TestDatabase_ToSqlJsonTag(t *testing.T) {
+	type Address struct {
+		City string `json:"city"`
+	}
+	type UsersJson struct {
+		Id      int      `db:"id,pk"`
+		Meta    Address  `db:"meta,json"`
+		Tags    []string `db:"tags,json"`
+		Options *Address `db:"options,json"`
+	}
+	prepare, values, err := Open("mysql").NewDatabase().ToSqlInsert(&UsersJson{Meta: Address{City: "Berlin"}, Tags: []string{"go"}})
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, "INSERT INTO `UsersJson` (`meta`,`tags`) VALUES (?,?) ", prepare)
+	driver.AssertsEqual(t, []any{`{"city":"Berlin"}`, `["go"]`}, values)
+}
+
+func TestDatabase_ToSqlFullText(t *testing.T) {
+	var cols = []string{"title", "body"}
+	var expect = map[string]string{
+		"mysql":      "SELECT MATCH(`title`, `body`) AGAINST(? IN BOOLEAN MODE) AS `score` FROM `posts` WHERE MATCH(`title`, `body`) AGAINST(? IN BOOLEAN MODE) OR MATCH(`body`) AGAINST(? IN NATURAL LANGUAGE MODE) ORDER BY MATCH(`title`, `body`) AGAINST(? IN BOOLEAN MODE) DESC",
+		"postgresql": `SELECT ts_rank(to_tsvector(coalesce("title", '') || ' ' || coalesce("body", '')), plainto_tsquery($1)) AS "score" FROM "posts" WHERE to_tsvector(coalesce("title", '') || ' ' || coalesce("body", '')) @@ plainto_tsquery($2) OR to_tsvector('english', "body") @@ plainto_tsquery('english', $3) ORDER BY ts_rank(to_tsvector(coalesce("title", '') || ' ' || coalesce("body", '')), plainto_tsquery($4)) DESC`,
+		"sqlite3":    `SELECT -rank AS "score" FROM "posts" WHERE ("title" MATCH ? OR "body" MATCH ?) OR "body" MATCH ? ORDER BY -rank DESC`,
+	}
+	for name, sql := range expect {
+		prepare, _, err := Open(name).NewDatabase().Table("posts").
+			WhereFullText(cols, "golang orm").
+			OrWhereFullText([]string{"body"}, "golang", FullTextOptions{Mode: FullTextNatural, Language: "english"}).
+			SelectFullTextScore(cols, "golang orm", "score").
+			OrderByFullTextScore(cols, "golang orm").
+			ToSql()
+		driver.AssertsError(t, err)
+		driver.AssertsEqual(t, sql, prepare)
+	}
+
+	prepare, values, err := Open("mssql").NewDatabase().Table("posts").
+		WhereFullText(cols, "golang").
+		OrWhereFullText([]string{"body"}, "golang", FullTextOptions{Mode: FullTextNatural}).
+		ToSql()
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, "SELECT * FROM [posts] WHERE CONTAINS(([title], [body]), @p1) OR FREETEXT([body], @p2)", prepare)
+	driver.AssertsEqual(t, []any{"golang", "golang"}, values)
+
+	prepare, _, err = Open("oracle").NewDatabase().Table("posts").WhereFullText(cols, "golang").ToSql()
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, `SELECT * FROM "posts" WHERE (CONTAINS("title", @p1) > 0 OR CONTAINS("body", @p2) > 0)`, prepare)
+
+	_, _, err = Open("mssql").NewDatabase().Table("posts").OrderByFullTextScore(cols, "golang").ToSql()
+	if err == nil {
+		t.Error("full text score on mssql should return error")
+	}
+
+	// boolean 模式的 +golang -php 在 postgresql 下为 websearch_to_tsquery
+	prepare, _, err = Open("postgresql").NewDatabase().Table("posts").
+		WhereFullText([]string{"body"}, "+golang -php", FullTextOptions{Mode: FullTextBoolean}).ToSql()
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, `SELECT * FROM "posts" WHERE to_tsvector("body") @@ websearch_to_tsquery($1)`, prepare)
+}
+
+func TestDatabase_ToSqlLock(t *testing.T) {
+	var expect = map[string][]string{
+		"mysql": {
+			"SELECT * FROM `jobs` WHERE `status` = ? ORDER BY `id` LIMIT 10 FOR UPDATE SKIP LOCKED",
+			"SELECT * FROM `jobs` FOR UPDATE OF `jobs` NOWAIT",
+			"SELECT * FROM `jobs` LOCK IN SHARE MODE",
+		},
+		"postgresql": {
+			`SELECT * FROM "jobs" WHERE "status" = $1 ORDER BY "id" LIMIT 10 FOR UPDATE SKIP LOCKED`,
+			`SELECT * FROM "jobs" FOR NO KEY UPDATE OF "jobs" NOWAIT`,
+			`SELECT * FROM "jobs" FOR SHARE`,
+		},
+		"mssql": {
+			`SELECT * FROM [jobs] WITH (UPDLOCK, READPAST) WHERE [status] = @p1 ORDER BY [id] OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY`,
+			"", // 表提示不支持 LockOf
+			"", // 不支持 share
+		},
+		"oracle": {
+			`SELECT * FROM "jobs" WHERE "status" = @p1 ORDER BY "id" OFFSET 0 ROWS FETCH NEXT 10 ROWS ONLY FOR UPDATE SKIP LOCKED`,
+			"", // FOR UPDATE OF 为列, 不支持 LockOf
+			"",
+		},
+		"sqlite3": {
+			"",
+			"",
+			"",
+		},
+	}
+	for name, sqls := range expect {
+		db := Open(name).NewDatabase()
+		var queries = []*Database{
+			db.Table("jobs").Where("status", "pending").OrderBy("id").Limit(10).LockForUpdate().SkipLocked(),
+			Open(name).NewDatabase().Table("jobs").LockForNoKeyUpdate().LockOf("jobs").NoWait(),
+			Open(name).NewDatabase().Table("jobs").SharedLock(),
+		}
+		for i, query := range queries {
+			prepare, _, err := query.ToSql()
+			if sqls[i] == "" { // 方言不支持的锁定方式返回错误
+				if err == nil {
+					t.Errorf("%s: expect error for lock case %d, got %s", name, i, prepare)
+				}
+				continue
+			}
+			driver.AssertsError(t, err)
+			driver.AssertsEqual(t, sqls[i], prepare)
+		}
+	}
+	prepare, _, err := Open("mssql").NewDatabase().Table("jobs").LockForUpdate().NoWait().ToSql()
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, `SELECT * FROM [jobs] WITH (UPDLOCK, ROWLOCK, NOWAIT)`, prepare)
+
+	prepare, _, err = Open("sqlite3").NewDatabase().Table("jobs").LockForUpdate().ToSql()
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, `SELECT * FROM "jobs"`, prepare)
+
+	// SkipLocked, NoWait, LockOf 需要与 LockForUpdate 等一起使用
+	_, _, err = Open("postgresql").NewDatabase().Table("jobs").SkipLocked().ToSql()
+	if err == nil {
+		t.Error("expect error for skip locked without lock")
+	}
+}
+
+func TestDatabase_ToSqlInsertUsing(t *testing.T) {
+	var expect = map[string][]string{
+		"mysql": {
+			"INSERT INTO `users_archive` (`id`,`name`) SELECT `id`, `name` FROM `users` WHERE `deleted` = ? AND `age` > ?",
+			"INSERT IGNORE INTO `users_archive` (`id`,`name`) SELECT `id`, `name` FROM `users` WHERE `deleted` = ? AND `age` > ?",
+			"INSERT INTO `users_archive` (`id`,`name`) SELECT `id`, `name` FROM `users` WHERE `deleted` = ? AND `age` > ? ON DUPLICATE KEY UPDATE `name`=VALUES(`name`)",
+		},
+		"postgresql": {
+			`INSERT INTO "users_archive" ("id","name") SELECT "id", "name" FROM "users" WHERE "deleted" = $1 AND "age" > $2`,
+			`INSERT INTO "users_archive" ("id","name") SELECT "id", "name" FROM "users" WHERE "deleted" = $1 AND "age" > $2 ON CONFLICT ("id") DO NOTHING`,
+			`INSERT INTO "users_archive" ("id","name") SELECT "id", "name" FROM "users" WHERE "deleted" = $1 AND "age" > $2 ON CONFLICT ("id") DO UPDATE SET "name"=excluded."name"`,
+		},
+		"sqlite3": {
+			`INSERT INTO "users_archive" ("id","name") SELECT "id", "name" FROM "users" WHERE "deleted" = ? AND "age" > ?`,
+			`INSERT OR IGNORE INTO "users_archive" ("id","name") SELECT "id", "name" FROM "users" WHERE "deleted" = ? AND "age" > ?`,
+			`INSERT INTO "users_archive" ("id","name") SELECT "id", "name" FROM "users" WHERE "deleted" = ? AND "age" > ? ON CONFLICT ("id") DO UPDATE SET "name"=excluded."name"`,
+		},
+		"mssql": {
+			`INSERT INTO [users_archive] ([id],[name]) SELECT [id], [name] FROM [users] WHERE [deleted] = @p1 AND [age] > @p2`,
+			"", // 需要 MERGE, 不支持
+			"",
+		},
+	}
+	for name, sqls := range expect {
+		var cases = []builder.TypeToSqlInsertCase{{}, {IsIgnoreCase: true, OnDuplicateKeys: []string{"id"}}, {OnDuplicateKeys: []string{"id"}, UpdateFields: []string{"name"}}}
+		for i, sql := range sqls {
+			var gr = Open(name)
+			var query = gr.NewDatabase().Table("users").Select("id", "name").Where("deleted", 1).Where("age", ">", 18)
+			var insertCase = cases[i]
+			insertCase.UsingColumns, insertCase.Using = []string{"id", "name"}, query
+			prepare, values, err := gr.NewDatabase().Table("users_archive").ToSqlInsert(nil, insertCase)
+			if sql == "" {
+				if err == nil {
+					t.Errorf("%s: expect error for insert case %d, got %s", name, i, prepare)
+				}
+				continue
+			}
+			driver.AssertsError(t, err)
+			driver.AssertsEqual(t, sql, strings.TrimSpace(prepare))
+			driver.AssertsEqual(t, []any{1, 18}, values)
+		}
+	}
+
+	// ON CONFLICT DO UPDATE 必须指定冲突的唯一键
+	_, _, err := Open("postgresql").NewDatabase().Table("users_archive").
+		ToSqlInsert(map[string]any{"id": 1, "name": "x"}, builder.TypeToSqlInsertCase{UpdateFields: []string{"name"}})
+	if err == nil {
+		t.Error("expect error for upsert without conflict keys")
+	}
+}
+
+func TestDatabase_ToSqlUpdateDeleteJoin(t *testing.T) {
+	var expect = map[string][]string{
+		"mysql": {
+			"UPDATE `orders` INNER JOIN `users` ON `orders`.`uid` = `users`.`id` SET `orders`.`status` = ? WHERE `users`.`banned` = ?",
+			"DELETE `orders` FROM `orders` INNER JOIN `users` ON `orders`.`uid` = `users`.`id` WHERE `users`.`banned` = ?",
+			"UPDATE `orders` SET `status` = ? WHERE `status` = ? ORDER BY `id` LIMIT 10",
+			"DELETE FROM `orders` WHERE `status` = ? ORDER BY `id` LIMIT 10",
+		},
+		"postgresql": {
+			`UPDATE "orders" SET "orders"."status" = $1 FROM "users" WHERE "orders"."uid" = "users"."id" AND ("users"."banned" = $2)`,
+			`DELETE FROM "orders" USING "users" WHERE "orders"."uid" = "users"."id" AND ("users"."banned" = $1)`,
+		},
+		"mssql": {
+			`UPDATE [orders] SET [orders].[status] = @p1 FROM [orders] INNER JOIN [users] ON [orders].[uid] = [users].[id] WHERE [users].[banned] = @p2`,
+			`DELETE [orders] FROM [orders] INNER JOIN [users] ON [orders].[uid] = [users].[id] WHERE [users].[banned] = @p1`,
+			`UPDATE TOP (10) [orders] SET [status] = @p1 WHERE [status] = @p2`,
+			`DELETE TOP (10) FROM [orders] WHERE [status] = @p1`,
+		},
+	}
+	for name, sqls := range expect {
+		prepare, values, err := Open(name).NewDatabase().Table("orders").Join("users", "orders.uid", "users.id").Where("users.banned", 1).
+			ToSqlUpdate(map[string]any{"orders.status": "closed"})
+		driver.AssertsError(t, err)
+		driver.AssertsEqual(t, sqls[0], prepare)
+		driver.AssertsEqual(t, []any{"closed", 1}, values)
+
+		prepare, _, err = Open(name).NewDatabase().Table("orders").Join("users", "orders.uid", "users.id").Where("users.banned", 1).ToSqlDelete(nil)
+		driver.AssertsError(t, err)
+		driver.AssertsEqual(t, sqls[1], prepare)
+
+		if len(sqls) < 4 {
+			continue
+		}
+		prepare, values, err = Open(name).NewDatabase().Table("orders").Where("status", "new").OrderBy("id").Limit(10).
+			ToSqlUpdate(map[string]any{"status": "closed"})
+		if name == "mssql" {
+			if err == nil {
+				t.Error("update with order by on mssql should return error")
+			}
+			prepare, values, err = Open(name).NewDatabase().Table("orders").Where("status", "new").Limit(10).
+				ToSqlUpdate(map[string]any{"status": "closed"})
+		}
+		driver.AssertsError(t, err)
+		driver.AssertsEqual(t, sqls[2], prepare)
+		driver.AssertsEqual(t, []any{"closed", "new"}, values)
+
+		var deletes = Open(name).NewDatabase().Table("orders").Where("status", "new").Limit(10)
+		if name != "mssql" {
+			deletes = deletes.OrderBy("id")
+		}
+		prepare, _, err = deletes.ToSqlDelete(nil)
+		driver.AssertsError(t, err)
+		driver.AssertsEqual(t, sqls[3], prepare)
+	}
+}
+
+func TestDatabase_ToSqlUpdateDeleteUnsupported(t *testing.T) {
+	var cases = map[string]func() error{
+		"postgresql limit": func() error {
+			_, _, err := Open("postgresql").NewDatabase().Table("orders").Where("id", 1).Limit(1).ToSqlUpdate(map[string]any{"a": 1})
+			return err
+		},
+		"mysql join with limit": func() error {
+			_, _, err := Open("mysql").NewDatabase().Table("orders").Join("users", "orders.uid", "users.id").Limit(1).ToSqlUpdate(map[string]any{"a": 1})
+			return err
+		},
+		"postgresql left join": func() error {
+			_, _, err := Open("postgresql").NewDatabase().Table("orders").LeftJoin("users", "orders.uid", "users.id").Where("id", 1).ToSqlDelete(nil)
+			return err
+		},
+		"sqlite3 delete join": func() error {
+			_, _, err := Open("sqlite3").NewDatabase().Table("orders").Join("users", "orders.uid", "users.id").Where("id", 1).ToSqlDelete(nil)
+			return err
+		},
+		"oracle update join": func() error {
+			_, _, err := Open("oracle").NewDatabase().Table("orders").Join("users", "orders.uid", "users.id").ToSqlUpdate(map[string]any{"a": 1})
+			return err
+		},
+		"mysql offset": func() error {
+			_, _, err := Open("mysql").NewDatabase().Table("orders").Where("id", 1).Limit(1).Offset(2).ToSqlDelete(nil)
+			return err
+		},
+		"delete without where": func() error {
+			_, _, err := Open("mysql").NewDatabase().Table("orders").ToSqlDelete(nil)
+			return err
+		},
+	}
+	for name, fn := range cases {
+		if fn() == nil {
+			t.Errorf("%s should return error", name)
+		}
+	}
+}
+
+func TestDatabase_ToSqlUpdateBatch(t *testing.T) {
+	var rows = []map[string]any{{"id": 1, "score": 90, "name": "a"}, {"id": 2, "score": 85}}
+	var expect = map[string]string{
+		"mysql":      "UPDATE `users` SET `name` = CASE `id` WHEN ? THEN ? ELSE `name` END, `score` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? ELSE `score` END WHERE `status` = ? AND `id` IN (?,?)",
+		"postgresql": `UPDATE "users" SET "name" = CASE "id" WHEN $1 THEN $2 ELSE "name" END, "score" = CASE "id" WHEN $3 THEN $4 WHEN $5 THEN $6 ELSE "score" END WHERE "status" = $7 AND "id" IN ($8,$9)`,
+	}
+	for name, sql := range expect {
+		sqls, binds, err := Open(name).NewDatabase().Table("users").Where("status", 1).ToSqlUpdateBatch(rows, "id")
+		driver.AssertsError(t, err)
+		driver.AssertsEqual(t, []string{sql}, sqls)
+		driver.AssertsEqual(t, [][]any{{1, "a", 1, 90, 2, 85, 1, 1, 2}}, binds)
+	}
+
+	// 结构体切片默认以主键匹配
+	var users = []User{{Id: 1, Name: "John"}, {Id: 2, Name: "Alice"}}
+	sqls, binds, err := Open("mysql").NewDatabase().ToSqlUpdateBatch(users, "")
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, []string{"UPDATE `User` SET `name` = CASE `id` WHEN ? THEN ? WHEN ? THEN ? ELSE `name` END WHERE `id` IN (?,?)"}, sqls)
+	driver.AssertsEqual(t, [][]any{{1, "John", 2, "Alice", 1, 2}}, binds)
+
+	// mssql 绑定参数上限按 2000 计(2100 中留出 sp_executesql 自身的参数), 每行 3 个参数, 每批 666 行
+	var many []map[string]any
+	for i := 1; i <= 1500; i++ {
+		many = append(many, map[string]any{"id": i, "score": i})
+	}
+	sqls, binds, err = Open("mssql").NewDatabase().Table("users").ToSqlUpdateBatch(many, "id")
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, 3, len(sqls))
+	driver.AssertsEqual(t, []int{1998, 1998, 504}, []int{len(binds[0]), len(binds[1]), len(binds[2])})
+	if !strings.Contains(sqls[1], "@p1998") || strings.Contains(sqls[1], "@p1999") {
+		t.Error("update batch placeholders should be numbered per statement")
+	}
+
+	// 表达式的值按实际的绑定参数计, 每行 4 个参数, 每批 500 行
+	many = many[:0]
+	for i := 1; i <= 1200; i++ {
+		many = append(many, map[string]any{"id": i, "score": Raw("score + ? * ?", i, 2)})
+	}
+	_, binds, err = Open("mssql").NewDatabase().Table("users").ToSqlUpdateBatch(many, "id")
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, []int{2000, 2000, 800}, []int{len(binds[0]), len(binds[1]), len(binds[2])})
+
+	_, _, err = Open("mysql").NewDatabase().Table("users").ToSqlUpdateBatch([]map[string]any{{"score": 1}}, "id")
+	if err == nil {
+		t.Error("update batch row without key should return error")
+	}
+}
+
+func TestDatabase_UpdateBatch(t *testing.T) {
+	var many []map[string]any
+	for i := 1; i <= 30000; i++ {
+		many = append(many, map[string]any{"id": i, "score": i})
+	}
+	g, fake := newFake(t)
+	affected, err := g.NewDatabase().Table("users").UpdateBatch(many, "id")
+	driver.AssertsError(t, err)
+	driver.AssertsEqual(t, int64(2), affected)
+	driver.AssertsEqual(t, int64(2), fake.executed.Load())
+}
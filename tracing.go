@@ -0,0 +1,181 @@
+package gorose
+
+import (
+	"context"
+	"github.com/gohouse/gorose/v3/driver/dialect"
+	"strings"
+	"time"
+)
+
+// Tracer 链路追踪, 每条语句和每个事务各产生一个 span;
+// 核心包不依赖任何追踪库, opentelemetry 的实现见 otel 子包
+type Tracer interface {
+	// Start 以 ctx 中的 span 为父 span 开始一个新的 span, 返回包含新 span 的 ctx
+	Start(ctx context.Context, name string, attrs ...TraceAttr) (context.Context, Span)
+}
+
+// Span 一次语句或事务的追踪
+type Span interface {
+	SetAttributes(attrs ...TraceAttr)
+	RecordError(err error)
+	End()
+}
+
+// TraceAttr span 属性, Value 为 string/int64/bool
+type TraceAttr struct {
+	Key   string
+	Value any
+}
+
+// span 属性名, 与 opentelemetry 数据库语义约定一致
+const (
+	TraceAttrDbSystem    = "db.system"
+	TraceAttrDbStatement = "db.statement"
+	TraceAttrDbOperation = "db.operation"
+	TraceAttrDbTable     = "db.sql.table"
+	TraceAttrDbNode      = "db.gorose.node"
+	TraceAttrDbRows      = "db.gorose.rows"
+	TraceAttrTxStatus    = "db.gorose.transaction"
+)
+
+// UseTracer 设置链路追踪, 父 span 通过 Engin.WithContext()/Database.WithContext() 传入
+func (g *GoRose) UseTracer(t Tracer) *GoRose {
+	g.tracer = t
+	return g
+}
+
+// WithContext 设置后续语句使用的 context, 用于超时控制和链路追踪
+func (s *Engin) WithContext(ctx context.Context) *Engin {
+	s.ctx = ctx
+	return s
+}
+
+// WithContext 同 Engin.WithContext
+func (db *Database) WithContext(ctx context.Context) *Database {
+	db = db.builder()
+	db.Engin.WithContext(ctx)
+	return db
+}
+
+// context 语句执行使用的 context, 事务中以事务的 span 为父 span
+func (s *Engin) context() context.Context {
+	if s.txCtx != nil {
+		return s.txCtx
+	}
+	if s.ctx != nil {
+		return s.ctx
+	}
+	return context.Background()
+}
+
+// trace 开始执行一条语句, 返回执行使用的 context 和执行完毕后的回调, 回调结束 span 并记录日志和监控
+func (s *Engin) trace(query string) (ctx context.Context, done func(item SqlItem)) {
+	var start = time.Now()
+	var span Span
+	ctx = s.context()
+	if s.tracer != nil {
+		operation, table := sqlOperation(query)
+		ctx, span = s.tracer.Start(ctx, strings.TrimSpace(operation+" "+table),
+			TraceAttr{Key: TraceAttrDbSystem, Value: dbSystem(s.driver)},
+			TraceAttr{Key: TraceAttrDbStatement, Value: sanitizeSql(query)},
+			TraceAttr{Key: TraceAttrDbOperation, Value: operation},
+			TraceAttr{Key: TraceAttrDbTable, Value: table},
+		)
+	}
+	return ctx, func(item SqlItem) {
+		item.Duration = time.Since(start)
+		if span != nil {
+			span.SetAttributes(TraceAttr{Key: TraceAttrDbNode, Value: item.Node}, TraceAttr{Key: TraceAttrDbRows, Value: item.Rows})
+			if item.Err != nil {
+				span.RecordError(item.Err)
+			}
+			span.End()
+		}
+		s.observe(item)
+	}
+}
+
+// traceTx 开始事务的 span, 事务中的语句以其为父 span
+func (s *Engin) traceTx() context.Context {
+	if s.tracer != nil {
+		s.txCtx, s.txSpan = s.tracer.Start(s.context(), "transaction", TraceAttr{Key: TraceAttrDbSystem, Value: dbSystem(s.driver)})
+	}
+	return s.context()
+}
+
+// traceTxEnd 结束事务的 span, status 为 commit 或 rollback
+func (s *Engin) traceTxEnd(status string, err error) {
+	if s.txSpan != nil {
+		s.txSpan.SetAttributes(TraceAttr{Key: TraceAttrTxStatus, Value: status})
+		if err != nil {
+			s.txSpan.RecordError(err)
+		}
+		s.txSpan.End()
+	}
+	s.txCtx, s.txSpan = nil, nil
+}
+
+// dbSystem 驱动对应的 db.system 值
+func dbSystem(driverName string) string {
+	switch dialect.GetDialect(driverName).(type) {
+	case *dialect.MySQLDialect:
+		return "mysql"
+	case *dialect.PostgresqlDialect:
+		return "postgresql"
+	case *dialect.MsSQLDialect:
+		return "mssql"
+	case *dialect.OracleDialect:
+		return "oracle"
+	case *dialect.SQLite3Dialect:
+		return "sqlite"
+	default:
+		return driverName
+	}
+}
+
+// sanitizeSql 将语句中的字符串和数字字面量替换为 ?, 避免敏感数据进入追踪系统; 标识符和占位符保持不变
+func sanitizeSql(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	for i := 0; i < len(query); i++ {
+		c := query[i]
+		switch {
+		case c == '\'':
+			for i++; i < len(query); i++ {
+				if query[i] == '\'' {
+					if i+1 < len(query) && query[i+1] == '\'' {
+						i++
+						continue
+					}
+					break
+				}
+			}
+			b.WriteByte('?')
+		case c == '"' || c == '`' || c == '[':
+			end := c
+			if c == '[' {
+				end = ']'
+			}
+			j := strings.IndexByte(query[i+1:], end)
+			if j < 0 {
+				b.WriteString(query[i:])
+				return b.String()
+			}
+			b.WriteString(query[i : i+j+2])
+			i += j + 1
+		case c >= '0' && c <= '9' && (i == 0 || !isIdentByte(query[i-1])):
+			for i+1 < len(query) && (query[i+1] >= '0' && query[i+1] <= '9' || query[i+1] == '.') {
+				i++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// isIdentByte 标识符或占位符($1, @p1)中的字符
+func isIdentByte(c byte) bool {
+	return c == '_' || c == '$' || c == '@' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9'
+}
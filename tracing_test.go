@@ -0,0 +1,108 @@
+package gorose
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+)
+
+type fakeSpanKey struct{}
+
+type fakeSpan struct {
+	name   string
+	parent *fakeSpan
+	attrs  map[string]any
+	err    error
+	ended  bool
+}
+
+func (s *fakeSpan) SetAttributes(attrs ...TraceAttr) {
+	for _, attr := range attrs {
+		s.attrs[attr.Key] = attr.Value
+	}
+}
+func (s *fakeSpan) RecordError(err error) { s.err = err }
+func (s *fakeSpan) End()                  { s.ended = true }
+
+type fakeTracer struct {
+	spans []*fakeSpan
+}
+
+func (t *fakeTracer) Start(ctx context.Context, name string, attrs ...TraceAttr) (context.Context, Span) {
+	var span = &fakeSpan{name: name, attrs: map[string]any{}}
+	span.parent, _ = ctx.Value(fakeSpanKey{}).(*fakeSpan)
+	span.SetAttributes(attrs...)
+	t.spans = append(t.spans, span)
+	return context.WithValue(ctx, fakeSpanKey{}, span), span
+}
+
+func TestEngin_Trace(t *testing.T) {
+	var tracer = &fakeTracer{}
+	var root = &fakeSpan{name: "request", attrs: map[string]any{}}
+	var ctx = context.WithValue(context.Background(), fakeSpanKey{}, root)
+	g, fake := newFake(t)
+	var engin = g.UseTracer(tracer).NewEngin().WithContext(ctx)
+
+	var errAbort = errors.New("abort")
+	err := engin.Transaction(func(e *Engin) error {
+		if _, err := e.Exec("UPDATE `users` SET `name` = 'john' WHERE `id` = 1"); err != nil {
+			return err
+		}
+		return errAbort
+	})
+	if err != nil && !errors.Is(err, errAbort) {
+		t.Fatal(err)
+	}
+	var posts []benchPost
+	if err = engin.QueryTo(&posts, "SELECT * FROM posts WHERE id > $1", 10); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(tracer.spans) != 3 {
+		t.Fatalf("expect 3 spans, got %d", len(tracer.spans))
+	}
+	tx, update, query := tracer.spans[0], tracer.spans[1], tracer.spans[2]
+	if tx.name != "transaction" || tx.parent != root || !tx.ended || tx.attrs[TraceAttrTxStatus] != "rollback" {
+		t.Errorf("unexpected transaction span: %+v", tx)
+	}
+	if update.name != "update users" || update.parent != tx || !update.ended {
+		t.Errorf("unexpected update span: %+v", update)
+	}
+	if got := update.attrs[TraceAttrDbStatement]; got != "UPDATE `users` SET `name` = ? WHERE `id` = ?" {
+		t.Errorf("statement not sanitized: %v", got)
+	}
+	if update.attrs[TraceAttrDbSystem] != "mysql" || update.attrs[TraceAttrDbOperation] != "update" || update.attrs[TraceAttrDbTable] != "users" {
+		t.Errorf("unexpected update span attributes: %v", update.attrs)
+	}
+	if query.parent != root || query.attrs[TraceAttrDbStatement] != "SELECT * FROM posts WHERE id > $1" || query.attrs[TraceAttrDbRows] != int64(fake.rows) {
+		t.Errorf("unexpected query span: %+v", query)
+	}
+}
+
+// 不可变模式下并发设置 context/记录器/预演, 需以 go test -race 运行
+func TestDatabase_WithContextConcurrent(t *testing.T) {
+	g, _ := newFake(t)
+	var base = g.NewDatabase().Immutable().Table("users")
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var ctx = context.WithValue(context.Background(), fakeSpanKey{}, i)
+			if _, err := base.WithContext(ctx).Count(); err != nil {
+				t.Error(err)
+			}
+			if _, err := base.Record(NewRecorder(10)).Count(); err != nil {
+				t.Error(err)
+			}
+			if _, err := base.DryRun().Where("id", i).Delete(nil); err != nil {
+				t.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+	if base.ctx != nil || base.recorder != nil || base.dryRun != nil {
+		t.Error("immutable base should not be modified")
+	}
+}
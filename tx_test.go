@@ -0,0 +1,125 @@
+package gorose
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestEngin_NestedTransaction(t *testing.T) {
+	var rec = NewRecorder(20)
+	g, _ := newFake(t)
+	var engin = g.NewEngin().Record(rec)
+	var errAbort = errors.New("abort")
+
+	err := engin.Transaction(func(e *Engin) error {
+		if _, err := e.Exec("UPDATE a SET n = 1"); err != nil {
+			return err
+		}
+		if err := e.Transaction(func(e *Engin) error {
+			_, err := e.Exec("UPDATE b SET n = 1")
+			return err
+		}); err != nil {
+			return err
+		}
+		if err := e.Transaction(func(e *Engin) error {
+			return errAbort
+		}); !errors.Is(err, errAbort) {
+			t.Errorf("inner transaction should surface closure error, got %v", err)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sqls []string
+	for _, item := range rec.Items() {
+		sqls = append(sqls, item.Sql)
+	}
+	var expect = []string{
+		"UPDATE a SET n = 1",
+		"SAVEPOINT `gorose_sp_1`",
+		"UPDATE b SET n = 1",
+		"RELEASE SAVEPOINT `gorose_sp_1`",
+		"SAVEPOINT `gorose_sp_1`",
+		"ROLLBACK TO SAVEPOINT `gorose_sp_1`",
+	}
+	if strings.Join(sqls, "\n") != strings.Join(expect, "\n") {
+		t.Errorf("unexpected statements:\n%s", strings.Join(sqls, "\n"))
+	}
+	if engin.tx != nil || engin.autoSavePoint != 0 {
+		t.Error("transaction should be finished")
+	}
+
+	err = engin.Transaction(func(e *Engin) error { return errAbort })
+	if !errors.Is(err, errAbort) {
+		t.Errorf("transaction should surface closure error, got %v", err)
+	}
+}
+
+func TestEngin_BeginTxReadOnly(t *testing.T) {
+	var g = Open(&ConfigCluster{
+		WriteConf: []Config{{Driver: "gorose_fake", DSN: "master"}},
+		ReadConf:  []Config{{Driver: "gorose_fake", DSN: "slave"}},
+	})
+	var engin = g.NewEngin()
+	if err := engin.BeginTx(&sql.TxOptions{ReadOnly: true}); err != nil {
+		t.Fatal(err)
+	}
+	if engin.txNode != "slave#0" {
+		t.Errorf("read-only transaction should run on slave, got %s", engin.txNode)
+	}
+	if err := engin.Commit(); err != nil {
+		t.Fatal(err)
+	}
+	if err := engin.BeginTx(&sql.TxOptions{Isolation: sql.LevelDefault}); err != nil {
+		t.Fatal(err)
+	}
+	if engin.txNode != "master#0" {
+		t.Errorf("transaction should run on master, got %s", engin.txNode)
+	}
+	_ = engin.Rollback()
+}
+
+func TestEngin_AfterCommit(t *testing.T) {
+	g, _ := newFake(t)
+	var engin = g.NewEngin()
+	var events []string
+	var record = func(event string) func() {
+		return func() { events = append(events, event) }
+	}
+
+	engin.AfterCommit(record("immediate"))
+	err := engin.Transaction(func(e *Engin) error {
+		e.AfterCommit(record("outer commit")).AfterRollback(record("outer rollback"))
+		_ = e.Transaction(func(e *Engin) error {
+			e.AfterCommit(record("inner commit"))
+			return nil
+		})
+		_ = e.Transaction(func(e *Engin) error {
+			e.AfterCommit(record("discarded")).AfterRollback(record("inner rollback"))
+			return errors.New("abort")
+		})
+		if len(events) != 1 {
+			t.Errorf("callbacks should wait for the outermost commit, got %v", events)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var expect = []string{"immediate", "inner rollback", "outer commit", "inner commit"}
+	if strings.Join(events, ",") != strings.Join(expect, ",") {
+		t.Errorf("expect %v, got %v", expect, events)
+	}
+
+	events = nil
+	_ = engin.Transaction(func(e *Engin) error {
+		e.AfterCommit(record("commit")).AfterRollback(record("rollback"))
+		return errors.New("abort")
+	})
+	if strings.Join(events, ",") != "rollback" {
+		t.Errorf("expect only rollback callback, got %v", events)
+	}
+}
@@ -0,0 +1,70 @@
+package gorose
+
+// txCallbacks 一层事务(最外层事务或保存点)中注册的回调
+type txCallbacks struct {
+	commit   []func() // 最外层事务提交后执行
+	rollback []func() // 最外层事务回滚后执行
+	settled  []func() // 已回滚到保存点的回调, 最外层事务结束后无论结果都执行
+}
+
+// AfterCommit 注册最外层事务提交成功后执行的回调, 如发布事件;
+// 嵌套事务回滚到保存点时, 该层注册的回调被丢弃; 不在事务中时立即执行
+func (s *Engin) AfterCommit(fn func()) *Engin {
+	if s.tx == nil {
+		fn()
+		return s
+	}
+	level := &s.txCallbacks[len(s.txCallbacks)-1]
+	level.commit = append(level.commit, fn)
+	return s
+}
+
+// AfterRollback 注册事务回滚后执行的回调;
+// 嵌套事务回滚到保存点时, 该层注册的回调在最外层事务结束后执行; 不在事务中时立即执行
+func (s *Engin) AfterRollback(fn func()) *Engin {
+	if s.tx == nil {
+		fn()
+		return s
+	}
+	level := &s.txCallbacks[len(s.txCallbacks)-1]
+	level.rollback = append(level.rollback, fn)
+	return s
+}
+
+// pushTxCallbacks 开启一层事务
+func (s *Engin) pushTxCallbacks() {
+	s.txCallbacks = append(s.txCallbacks, txCallbacks{})
+}
+
+// popTxCallbacks 结束一层嵌套事务, 回调合并到上一层
+func (s *Engin) popTxCallbacks(committed bool) {
+	if len(s.txCallbacks) < 2 {
+		return
+	}
+	last := s.txCallbacks[len(s.txCallbacks)-1]
+	s.txCallbacks = s.txCallbacks[:len(s.txCallbacks)-1]
+	parent := &s.txCallbacks[len(s.txCallbacks)-1]
+	if committed {
+		parent.commit = append(parent.commit, last.commit...)
+		parent.rollback = append(parent.rollback, last.rollback...)
+	} else {
+		parent.settled = append(parent.settled, last.rollback...)
+	}
+	parent.settled = append(parent.settled, last.settled...)
+}
+
+// fireTxCallbacks 最外层事务结束后执行回调
+func (s *Engin) fireTxCallbacks(committed bool) {
+	if len(s.txCallbacks) == 0 {
+		return
+	}
+	root := s.txCallbacks[0]
+	s.txCallbacks = nil
+	var fns = root.rollback
+	if committed {
+		fns = root.commit
+	}
+	for _, fn := range append(root.settled, fns...) {
+		fn()
+	}
+}
@@ -17,6 +17,30 @@ func As(table any, alias string) builder.TableClause {
 		Alias:  alias,
 	}
 }
+
+// Expr 原生 sql 表达式, 参考 Raw
+type Expr = builder.Expr
+
+// Raw 原生 sql 表达式, 可以作为 Insert/Update 数据的值, Where 条件的列或值, Join 的关联列, OrderBy/GroupByExpr 的列,
+// 表达式原样输出, binds 按顺序合并到语句的绑定参数中, 如:
+//
+//	db.Table("users").Where("id", 1).Update(map[string]any{"updated_at": gorose.Raw("NOW()"), "score": gorose.Raw("score + ?", 5)})
+//	db.Table("users").Where("name", gorose.Raw("COALESCE(nickname, ?)", "guest")).Get()
+func Raw(sql string, binds ...any) Expr {
+	return Expr{Sql: sql, Binds: binds}
+}
+
+// FullTextOptions 全文检索选项, 参考 Database.WhereFullText
+type FullTextOptions = builder.FullTextOptions
+
+// 全文检索模式, 参考 builder.FullTextBoolean 等
+const (
+	FullTextBoolean   = builder.FullTextBoolean
+	FullTextNatural   = builder.FullTextNatural
+	FullTextExpansion = builder.FullTextExpansion
+	FullTextWebsearch = builder.FullTextWebsearch
+)
+
 func GetRandomInt(num int) int {
 	return rand.Intn(num)
 }